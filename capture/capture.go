@@ -0,0 +1,127 @@
+package capture
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-web/constants"
+	"github.com/pritunl/pritunl-web/errortypes"
+)
+
+var captureLock = sync.Mutex{}
+
+type Message struct {
+	Method  string          `json:"method,omitempty"`
+	Host    string          `json:"host,omitempty"`
+	Path    string          `json:"path,omitempty"`
+	Query   string          `json:"query,omitempty"`
+	Status  int             `json:"status,omitempty"`
+	Headers http.Header     `json:"headers,omitempty"`
+	Body    json.RawMessage `json:"body,omitempty"`
+}
+
+type Entry struct {
+	Id        string   `json:"id"`
+	Timestamp string   `json:"timestamp"`
+	Client    *Message `json:"client"`
+	Backend   *Message `json:"backend"`
+	Response  *Message `json:"response"`
+}
+
+func Enabled() bool {
+	return constants.CapturePath != ""
+}
+
+func NewId() (id string, err error) {
+	idByt := make([]byte, 8)
+	_, err = rand.Read(idByt)
+	if err != nil {
+		err = &errortypes.UnknownError{
+			errors.Wrap(err, "capture: Failed to generate id"),
+		}
+		return
+	}
+
+	id = hex.EncodeToString(idByt)
+	return
+}
+
+func Write(entry *Entry) (err error) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrap(err, "capture: Failed to marshal entry"),
+		}
+		return
+	}
+
+	captureLock.Lock()
+	defer captureLock.Unlock()
+
+	file, err := os.OpenFile(constants.CapturePath,
+		os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		err = &errortypes.WriteError{
+			errors.Wrap(err, "capture: Failed to open capture file"),
+		}
+		return
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(data, '\n'))
+	if err != nil {
+		err = &errortypes.WriteError{
+			errors.Wrap(err, "capture: Failed to write capture file"),
+		}
+		return
+	}
+
+	return
+}
+
+func Read(path string) (entries []*Entry, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "capture: Failed to open capture file"),
+		}
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		entry := &Entry{}
+		err = json.Unmarshal(scanner.Bytes(), entry)
+		if err != nil {
+			err = &errortypes.ParseError{
+				errors.Wrap(err, "capture: Failed to parse capture entry"),
+			}
+			return
+		}
+
+		entries = append(entries, entry)
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "capture: Failed to read capture file"),
+		}
+		return
+	}
+
+	return
+}
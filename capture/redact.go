@@ -0,0 +1,187 @@
+package capture
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const Redacted = "[redacted]"
+
+func RedactKey(key string) bool {
+	key = strings.ToLower(strings.ReplaceAll(key, "-", "_"))
+
+	switch key {
+	case "key", "authorization", "pin":
+		return true
+	}
+
+	if strings.HasSuffix(key, "_pin") {
+		return true
+	}
+
+	for _, word := range []string{
+		"password",
+		"passcode",
+		"secret",
+		"token",
+		"csrf",
+		"signature",
+	} {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+
+	return strings.HasSuffix(key, "_key") &&
+		!strings.HasSuffix(key, "public_key")
+}
+
+func redactCookies(val string) string {
+	cookies, err := http.ParseCookie(val)
+	if err != nil {
+		return Redacted
+	}
+
+	pairs := []string{}
+	for _, cookie := range cookies {
+		if RedactKey(cookie.Name) {
+			cookie.Value = Redacted
+		}
+		pairs = append(pairs, cookie.Name+"="+cookie.Value)
+	}
+
+	return strings.Join(pairs, "; ")
+}
+
+func redactSetCookie(val string) string {
+	cookie, err := http.ParseSetCookie(val)
+	if err != nil {
+		return Redacted
+	}
+
+	if RedactKey(cookie.Name) {
+		cookie.Value = Redacted
+	}
+
+	return cookie.Name + "=" + cookie.Value
+}
+
+func RedactHeaders(header http.Header) (redacted http.Header) {
+	redacted = http.Header{}
+
+	for key, vals := range header {
+		for _, val := range vals {
+			switch {
+			case RedactKey(key):
+				val = Redacted
+			case key == "Cookie":
+				val = redactCookies(val)
+			case key == "Set-Cookie":
+				val = redactSetCookie(val)
+			}
+			redacted[key] = append(redacted[key], val)
+		}
+	}
+
+	return
+}
+
+func RedactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return Redacted
+	}
+
+	for key := range query {
+		if RedactKey(key) || key == "code" || key == "state" {
+			query[key] = []string{Redacted}
+		}
+	}
+
+	return query.Encode()
+}
+
+func uriKey(key string) bool {
+	key = strings.ToLower(key)
+	return key == "uri" || key == "url" ||
+		strings.HasSuffix(key, "_uri") || strings.HasSuffix(key, "_url")
+}
+
+func redactUri(val string) string {
+	scheme := strings.Index(val, "://")
+	if scheme == -1 {
+		return val
+	}
+	start := scheme + 3
+
+	end := strings.IndexAny(val[start:], "/?#")
+	if end == -1 {
+		end = len(val)
+	} else {
+		end += start
+	}
+
+	at := strings.LastIndex(val[start:end], "@")
+	if at == -1 {
+		return val
+	}
+	at += start
+
+	userinfo := val[start:at]
+	sep := strings.Index(userinfo, ":")
+	if sep == -1 {
+		return val
+	}
+
+	return val[:start] + userinfo[:sep] + ":" + Redacted + val[at:]
+}
+
+func redactValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		for key, elem := range v {
+			if RedactKey(key) {
+				v[key] = Redacted
+			} else if str, ok := elem.(string); ok && uriKey(key) {
+				v[key] = redactUri(str)
+			} else {
+				v[key] = redactValue(elem)
+			}
+		}
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = redactValue(elem)
+		}
+	}
+
+	return val
+}
+
+func RedactBody(data []byte) json.RawMessage {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var val interface{}
+	err := decoder.Decode(&val)
+	if err != nil || decoder.More() {
+		return nil
+	}
+
+	redacted, err := json.Marshal(redactValue(val))
+	if err != nil {
+		return nil
+	}
+
+	return redacted
+}
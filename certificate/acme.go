@@ -0,0 +1,145 @@
+package certificate
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-web/constants"
+	"github.com/pritunl/pritunl-web/errortypes"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+const acmeAlpnProto = acme.ALPNProto
+
+var (
+	acmeManager   *autocert.Manager
+	acmeCerts     = map[string]*tls.Certificate{}
+	acmeCertsLock = sync.RWMutex{}
+)
+
+func AcmeEnabled() bool {
+	return acmeManager != nil
+}
+
+func AcmeHandler() http.Handler {
+	return acmeManager.HTTPHandler(nil)
+}
+
+func acmeChallenge(hello *tls.ClientHelloInfo) bool {
+	for _, proto := range hello.SupportedProtos {
+		if proto == acmeAlpnProto {
+			return true
+		}
+	}
+	return false
+}
+
+func acmeMatch(hello *tls.ClientHelloInfo) bool {
+	if acmeChallenge(hello) {
+		return true
+	}
+
+	for _, domain := range constants.Acme.Domains {
+		if strings.EqualFold(hello.ServerName, domain) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func acmeCertificate(hello *tls.ClientHelloInfo) (
+	cert *tls.Certificate, err error) {
+
+	if hello.ServerName == "" {
+		helloCopy := *hello
+		helloCopy.ServerName = constants.Acme.Domains[0]
+		hello = &helloCopy
+	}
+
+	cert, err = acmeManager.GetCertificate(hello)
+	if err != nil || acmeChallenge(hello) {
+		return
+	}
+
+	acmeCertsLock.Lock()
+	acmeCerts[strings.ToLower(hello.ServerName)] = cert
+	acmeCertsLock.Unlock()
+
+	cert = withStaple(cert)
+
+	return
+}
+
+func initAcme() (err error) {
+	if len(constants.Acme.Domains) == 0 {
+		return
+	}
+
+	directory := constants.Acme.Directory
+	if directory == "" {
+		directory = autocert.DefaultACMEDirectory
+	}
+
+	httpTransport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if constants.Acme.CaPath != "" {
+		caByt, e := os.ReadFile(constants.Acme.CaPath)
+		if e != nil {
+			err = &errortypes.ReadError{
+				errors.Wrap(e, "certificate: Failed to read ACME CA"),
+			}
+			return
+		}
+
+		rootCas := x509.NewCertPool()
+		if !rootCas.AppendCertsFromPEM(caByt) {
+			err = &errortypes.ParseError{
+				errors.New("certificate: No certificates found in ACME CA"),
+			}
+			return
+		}
+
+		httpTransport.TLSClientConfig = &tls.Config{
+			RootCAs: rootCas,
+		}
+	}
+
+	err = os.MkdirAll(constants.Acme.CachePath, 0700)
+	if err != nil {
+		err = &errortypes.WriteError{
+			errors.Wrap(err, "certificate: Failed to create ACME cache"),
+		}
+		return
+	}
+
+	acmeManager = &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(constants.Acme.CachePath),
+		HostPolicy: autocert.HostWhitelist(constants.Acme.Domains...),
+		Email:      constants.Acme.Email,
+		Client: &acme.Client{
+			DirectoryURL: directory,
+			HTTPClient: &http.Client{
+				Transport: httpTransport,
+				Timeout:   1 * time.Minute,
+			},
+		},
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"domains":   constants.Acme.Domains,
+		"directory": directory,
+		"cache":     constants.Acme.CachePath,
+	}).Info("certificate: ACME certificate management enabled")
+
+	return
+}
@@ -0,0 +1,111 @@
+//go:build pebble
+
+package certificate
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/pritunl/pritunl-web/constants"
+)
+
+// Requires a running Pebble server and pebble-challtestsrv resolving the
+// test domain to this host, run with:
+// PEBBLE_CA_PATH=pebble.minica.pem go test -tags pebble ./certificate/
+
+func pebbleEnv(key, def string) string {
+	val := os.Getenv(key)
+	if val == "" {
+		return def
+	}
+	return val
+}
+
+func TestAcmePebble(t *testing.T) {
+	caPath := os.Getenv("PEBBLE_CA_PATH")
+	if caPath == "" {
+		t.Skip("PEBBLE_CA_PATH not set")
+	}
+
+	domain := pebbleEnv("PEBBLE_DOMAIN", "pritunl-web.test")
+
+	constants.Acme.Domains = []string{domain}
+	constants.Acme.Directory = pebbleEnv("PEBBLE_DIRECTORY",
+		"https://127.0.0.1:14000/dir")
+	constants.Acme.CaPath = caPath
+	constants.Acme.CachePath = t.TempDir()
+	t.Cleanup(func() {
+		constants.Acme.Domains = nil
+		constants.Acme.Directory = ""
+		constants.Acme.CaPath = ""
+		constants.Acme.CachePath = ""
+		acmeManager = nil
+	})
+
+	err := initAcme()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tlsListener, err := tls.Listen("tcp",
+		net.JoinHostPort("", pebbleEnv("PEBBLE_TLS_PORT", "5001")),
+		&tls.Config{
+			GetCertificate: GetCertificate,
+			NextProtos:     NextProtos([]string{"http/1.1"}),
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tlsServer := &http.Server{
+		Handler: http.NotFoundHandler(),
+	}
+	go tlsServer.Serve(tlsListener)
+	t.Cleanup(func() {
+		tlsServer.Close()
+	})
+
+	httpListener, err := net.Listen("tcp",
+		net.JoinHostPort("", pebbleEnv("PEBBLE_HTTP_PORT", "5002")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := &http.Server{
+		Handler: AcmeHandler(),
+	}
+	go httpServer.Serve(httpListener)
+	t.Cleanup(func() {
+		httpServer.Close()
+	})
+
+	cert, err := GetCertificate(&tls.ClientHelloInfo{
+		ServerName: domain,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cert.Leaf == nil || !slices.Contains(cert.Leaf.DNSNames, domain) {
+		t.Fatalf("certificate not issued for %s", domain)
+	}
+
+	entries, err := os.ReadDir(constants.Acme.CachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("certificate not written to cache")
+	}
+
+	cached, err := GetCertificate(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(cached.Certificate[0], cert.Certificate[0]) {
+		t.Fatal("cached certificate not reused")
+	}
+}
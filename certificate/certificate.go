@@ -0,0 +1,110 @@
+package certificate
+
+import (
+	"crypto/tls"
+	"sync"
+	"time"
+
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-web/constants"
+	"github.com/pritunl/pritunl-web/errortypes"
+)
+
+var (
+	certs     = newStore()
+	certsLock = sync.RWMutex{}
+)
+
+func GetCertificate(hello *tls.ClientHelloInfo) (
+	cert *tls.Certificate, err error) {
+
+	if acmeManager != nil && acmeMatch(hello) {
+		return acmeCertificate(hello)
+	}
+
+	certsLock.RLock()
+	cert = certs.get(hello.ServerName)
+	certsLock.RUnlock()
+
+	cert = withStaple(cert)
+
+	if cert == nil {
+		if acmeManager != nil {
+			return acmeCertificate(hello)
+		}
+
+		err = &errortypes.UnknownError{
+			errors.New("certificate: No certificate available"),
+		}
+		return
+	}
+
+	return
+}
+
+func NextProtos(base []string) []string {
+	protos := append([]string{}, base...)
+	if acmeManager != nil {
+		protos = append(protos, acmeAlpnProto)
+	}
+	return protos
+}
+
+func Reload() (err error) {
+	err = loadStore()
+	if err != nil {
+		return
+	}
+
+	if constants.Certs.OcspStapling {
+		go updateStaples()
+	}
+
+	return
+}
+
+func Init() (err error) {
+	err = loadStore()
+	if err != nil {
+		return
+	}
+
+	err = initAcme()
+	if err != nil {
+		return
+	}
+
+	initOcsp()
+
+	return
+}
+
+type Status struct {
+	Source    string    `json:"source"`
+	Names     []string  `json:"names"`
+	NotBefore time.Time `json:"not_before"`
+	NotAfter  time.Time `json:"not_after"`
+}
+
+func Check() (statuses []*Status, err error) {
+	certStore, err := buildStore()
+	if err != nil {
+		return
+	}
+
+	for i, cert := range certStore.certs {
+		names := cert.Leaf.DNSNames
+		if len(names) == 0 && cert.Leaf.Subject.CommonName != "" {
+			names = []string{cert.Leaf.Subject.CommonName}
+		}
+
+		statuses = append(statuses, &Status{
+			Source:    certStore.sources[i],
+			Names:     names,
+			NotBefore: cert.Leaf.NotBefore,
+			NotAfter:  cert.Leaf.NotAfter,
+		})
+	}
+
+	return
+}
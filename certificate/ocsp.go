@@ -0,0 +1,280 @@
+package certificate
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-web/constants"
+	"github.com/pritunl/pritunl-web/errortypes"
+	"github.com/pritunl/pritunl-web/metrics"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ocsp"
+)
+
+type staple struct {
+	labels     map[string]string
+	raw        []byte
+	thisUpdate time.Time
+	nextUpdate time.Time
+	lastTry    time.Time
+}
+
+var (
+	staples     = map[string]*staple{}
+	staplesLock = sync.RWMutex{}
+	ocspClient  = &http.Client{
+		Timeout: 30 * time.Second,
+	}
+)
+
+func leafId(cert *tls.Certificate) string {
+	hash := sha256.Sum256(cert.Certificate[0])
+	return hex.EncodeToString(hash[:])
+}
+
+func withStaple(cert *tls.Certificate) *tls.Certificate {
+	if !constants.Certs.OcspStapling || cert == nil ||
+		len(cert.Certificate) == 0 {
+
+		return cert
+	}
+
+	staplesLock.RLock()
+	stpl := staples[leafId(cert)]
+	staplesLock.RUnlock()
+
+	if stpl == nil || stpl.raw == nil || time.Now().After(stpl.nextUpdate) {
+		return cert
+	}
+
+	certCopy := *cert
+	certCopy.OCSPStaple = stpl.raw
+	return &certCopy
+}
+
+func fetchStaple(cert *tls.Certificate) (stpl *staple, err error) {
+	if len(cert.Certificate) < 2 {
+		err = &errortypes.ParseError{
+			errors.New("certificate: Missing issuer for OCSP request"),
+		}
+		return
+	}
+
+	issuer, err := x509.ParseCertificate(cert.Certificate[1])
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrap(err, "certificate: Failed to parse issuer"),
+		}
+		return
+	}
+
+	responder := constants.Certs.OcspResponder
+	if responder == "" {
+		if len(cert.Leaf.OCSPServer) == 0 {
+			err = &errortypes.ParseError{
+				errors.New("certificate: Certificate has no OCSP server"),
+			}
+			return
+		}
+		responder = cert.Leaf.OCSPServer[0]
+	}
+
+	reqByt, err := ocsp.CreateRequest(cert.Leaf, issuer, nil)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrap(err, "certificate: Failed to create OCSP request"),
+		}
+		return
+	}
+
+	resp, err := ocspClient.Post(responder, "application/ocsp-request",
+		bytes.NewReader(reqByt))
+	if err != nil {
+		err = &errortypes.RequestError{
+			errors.Wrap(err, "certificate: OCSP request failed"),
+		}
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		err = &errortypes.RequestError{
+			errors.Newf("certificate: OCSP responder returned %d",
+				resp.StatusCode),
+		}
+		return
+	}
+
+	respByt, err := io.ReadAll(io.LimitReader(resp.Body, 1048576))
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "certificate: Failed to read OCSP response"),
+		}
+		return
+	}
+
+	ocspResp, err := ocsp.ParseResponseForCert(respByt, cert.Leaf, issuer)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrap(err, "certificate: Failed to parse OCSP response"),
+		}
+		return
+	}
+
+	if ocspResp.Status != ocsp.Good {
+		err = &errortypes.ParseError{
+			errors.Newf("certificate: OCSP status is not good (%d)",
+				ocspResp.Status),
+		}
+		return
+	}
+
+	nextUpdate := ocspResp.NextUpdate
+	if nextUpdate.IsZero() {
+		nextUpdate = ocspResp.ThisUpdate.Add(24 * time.Hour)
+	}
+
+	stpl = &staple{
+		raw:        respByt,
+		thisUpdate: ocspResp.ThisUpdate,
+		nextUpdate: nextUpdate,
+	}
+
+	return
+}
+
+func stapleDue(stpl *staple) bool {
+	if stpl == nil {
+		return true
+	}
+
+	now := time.Now()
+	if now.Sub(stpl.lastTry) < 5*time.Minute {
+		return false
+	}
+
+	if stpl.raw == nil {
+		return true
+	}
+
+	refresh := stpl.thisUpdate.Add(stpl.nextUpdate.Sub(stpl.thisUpdate) / 2)
+	return now.After(refresh)
+}
+
+func updateStaple(cert *tls.Certificate) {
+	id := leafId(cert)
+	labels := map[string]string{
+		"id":   id,
+		"name": cert.Leaf.Subject.CommonName,
+	}
+
+	staplesLock.RLock()
+	cur := staples[id]
+	staplesLock.RUnlock()
+
+	if !stapleDue(cur) {
+		return
+	}
+
+	stpl, err := fetchStaple(cert)
+	if err != nil {
+		metrics.Add("pritunl_web_ocsp_errors_total", labels, 1)
+
+		logrus.WithFields(logrus.Fields{
+			"name":  cert.Leaf.Subject.CommonName,
+			"error": err,
+		}).Warn("certificate: Failed to update OCSP staple")
+
+		stpl = &staple{}
+		if cur != nil && time.Now().Before(cur.nextUpdate) {
+			*stpl = *cur
+		}
+	} else {
+		logrus.WithFields(logrus.Fields{
+			"name":        cert.Leaf.Subject.CommonName,
+			"this_update": stpl.thisUpdate,
+			"next_update": stpl.nextUpdate,
+		}).Info("certificate: Updated OCSP staple")
+	}
+	stpl.labels = labels
+	stpl.lastTry = time.Now()
+
+	staplesLock.Lock()
+	staples[id] = stpl
+	staplesLock.Unlock()
+
+	if stpl.raw != nil {
+		metrics.Set("pritunl_web_ocsp_staple", labels, 1)
+		metrics.Set("pritunl_web_ocsp_next_update_seconds", labels,
+			float64(stpl.nextUpdate.Unix()))
+	} else {
+		metrics.Set("pritunl_web_ocsp_staple", labels, 0)
+	}
+}
+
+func updateStaples() {
+	certsLock.RLock()
+	certList := append([]*tls.Certificate{}, certs.certs...)
+	certsLock.RUnlock()
+
+	acmeCertsLock.RLock()
+	for _, cert := range acmeCerts {
+		certList = append(certList, cert)
+	}
+	acmeCertsLock.RUnlock()
+
+	ids := map[string]bool{}
+	for _, cert := range certList {
+		if cert.Leaf == nil || len(cert.Certificate) == 0 {
+			continue
+		}
+		ids[leafId(cert)] = true
+		updateStaple(cert)
+	}
+
+	staplesLock.Lock()
+	for id, stpl := range staples {
+		if ids[id] {
+			continue
+		}
+		delete(staples, id)
+
+		if stpl.labels != nil {
+			metrics.Delete("pritunl_web_ocsp_staple", stpl.labels)
+			metrics.Delete("pritunl_web_ocsp_next_update_seconds",
+				stpl.labels)
+			metrics.Delete("pritunl_web_ocsp_errors_total", stpl.labels)
+		}
+	}
+	staplesLock.Unlock()
+}
+
+func ocspRunner() {
+	for {
+		updateStaples()
+		time.Sleep(1 * time.Minute)
+	}
+}
+
+func initOcsp() {
+	if !constants.Certs.OcspStapling {
+		return
+	}
+
+	metrics.Help("pritunl_web_ocsp_staple",
+		"Whether a valid OCSP staple is available for the certificate")
+	metrics.Help("pritunl_web_ocsp_next_update_seconds",
+		"Unix time of the next update of the OCSP staple")
+	metrics.Help("pritunl_web_ocsp_errors_total",
+		"Number of failed OCSP staple updates")
+
+	go ocspRunner()
+}
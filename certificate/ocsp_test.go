@@ -0,0 +1,373 @@
+package certificate
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pritunl/pritunl-web/constants"
+	"github.com/pritunl/pritunl-web/metrics"
+	"golang.org/x/crypto/ocsp"
+)
+
+type ocspResponder struct {
+	server   *httptest.Server
+	issuer   *x509.Certificate
+	key      crypto.Signer
+	lock     sync.Mutex
+	status   int
+	fail     bool
+	requests int
+}
+
+func newOcspResponder(t *testing.T, issuer *x509.Certificate,
+	key crypto.Signer) (responder *ocspResponder) {
+
+	responder = &ocspResponder{
+		issuer: issuer,
+		key:    key,
+		status: ocsp.Good,
+	}
+
+	responder.server = httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			responder.lock.Lock()
+			defer responder.lock.Unlock()
+
+			responder.requests += 1
+			if responder.fail {
+				http.Error(w, "Unavailable", 503)
+				return
+			}
+
+			body, _ := io.ReadAll(r.Body)
+			req, err := ocsp.ParseRequest(body)
+			if err != nil {
+				http.Error(w, err.Error(), 400)
+				return
+			}
+
+			now := time.Now()
+			resp, err := ocsp.CreateResponse(issuer, issuer, ocsp.Response{
+				Status:       responder.status,
+				SerialNumber: req.SerialNumber,
+				ThisUpdate:   now.Add(-1 * time.Hour),
+				NextUpdate:   now.Add(1 * time.Hour),
+				RevokedAt:    now.Add(-2 * time.Hour),
+			}, key)
+			if err != nil {
+				http.Error(w, err.Error(), 500)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/ocsp-response")
+			w.Write(resp)
+		}))
+	t.Cleanup(responder.server.Close)
+
+	return
+}
+
+func (r *ocspResponder) Set(status int, fail bool) {
+	r.lock.Lock()
+	r.status = status
+	r.fail = fail
+	r.lock.Unlock()
+}
+
+func (r *ocspResponder) Requests() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.requests
+}
+
+func newOcspChain(t *testing.T) (cert *tls.Certificate,
+	issuer *x509.Certificate, issuerKey crypto.Signer) {
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-1 * time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage: x509.KeyUsageCertSign | x509.KeyUsageCRLSign |
+			x509.KeyUsageDigitalSignature,
+	}
+
+	caDer, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl,
+		caKey.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuer, err = x509.ParseCertificate(caDer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leafTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "web.example.com"},
+		DNSNames:     []string{"web.example.com"},
+		NotBefore:    time.Now().Add(-1 * time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	leafDer, err := x509.CreateCertificate(rand.Reader, leafTmpl, issuer,
+		leafKey.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaf, err := x509.ParseCertificate(leafDer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert = &tls.Certificate{
+		Certificate: [][]byte{leafDer, caDer},
+		PrivateKey:  leafKey,
+		Leaf:        leaf,
+	}
+	issuerKey = caKey
+
+	return
+}
+
+func setupOcsp(t *testing.T) (cert *tls.Certificate,
+	responder *ocspResponder) {
+
+	cert, issuer, issuerKey := newOcspChain(t)
+	responder = newOcspResponder(t, issuer, issuerKey)
+
+	constants.Certs.OcspStapling = true
+	constants.Certs.OcspResponder = responder.server.URL
+
+	staplesLock.Lock()
+	staples = map[string]*staple{}
+	staplesLock.Unlock()
+
+	t.Cleanup(func() {
+		constants.Certs.OcspStapling = false
+		constants.Certs.OcspResponder = ""
+	})
+
+	return
+}
+
+func getStaple(cert *tls.Certificate) *staple {
+	staplesLock.RLock()
+	defer staplesLock.RUnlock()
+	return staples[leafId(cert)]
+}
+
+func expireLastTry(cert *tls.Certificate, thisUpdate,
+	nextUpdate time.Time) {
+
+	staplesLock.Lock()
+	stpl := staples[leafId(cert)]
+	stpl.lastTry = time.Now().Add(-10 * time.Minute)
+	if !thisUpdate.IsZero() {
+		stpl.thisUpdate = thisUpdate
+		stpl.nextUpdate = nextUpdate
+	}
+	staplesLock.Unlock()
+}
+
+func TestOcspStaple(t *testing.T) {
+	cert, responder := setupOcsp(t)
+
+	if withStaple(cert).OCSPStaple != nil {
+		t.Fatal("staple served before first update")
+	}
+
+	updateStaple(cert)
+
+	stpl := getStaple(cert)
+	if stpl == nil || stpl.raw == nil {
+		t.Fatal("staple missing after update")
+	}
+
+	served := withStaple(cert)
+	if !bytes.Equal(served.OCSPStaple, stpl.raw) {
+		t.Fatal("served certificate missing staple")
+	}
+	if cert.OCSPStaple != nil {
+		t.Fatal("stored certificate modified")
+	}
+
+	updateStaple(cert)
+	if responder.Requests() != 1 {
+		t.Fatalf("staple refreshed early, %d requests",
+			responder.Requests())
+	}
+}
+
+func TestOcspRefresh(t *testing.T) {
+	cert, responder := setupOcsp(t)
+
+	updateStaple(cert)
+	first := getStaple(cert).raw
+
+	now := time.Now()
+	expireLastTry(cert, now.Add(-2*time.Hour), now.Add(30*time.Minute))
+
+	updateStaple(cert)
+	if responder.Requests() != 2 {
+		t.Fatalf("staple not refreshed, %d requests", responder.Requests())
+	}
+
+	stpl := getStaple(cert)
+	if stpl.raw == nil || bytes.Equal(stpl.raw, first) {
+		t.Fatal("staple not replaced after refresh")
+	}
+	if !stpl.nextUpdate.After(now.Add(30 * time.Minute)) {
+		t.Fatal("staple next update not advanced")
+	}
+}
+
+func TestOcspDegrade(t *testing.T) {
+	cert, responder := setupOcsp(t)
+
+	updateStaple(cert)
+	first := getStaple(cert).raw
+
+	responder.Set(ocsp.Good, true)
+
+	now := time.Now()
+	expireLastTry(cert, now.Add(-2*time.Hour), now.Add(30*time.Minute))
+	updateStaple(cert)
+
+	if !bytes.Equal(withStaple(cert).OCSPStaple, first) {
+		t.Fatal("valid staple dropped after responder failure")
+	}
+
+	expireLastTry(cert, now.Add(-3*time.Hour), now.Add(-1*time.Minute))
+	updateStaple(cert)
+
+	if withStaple(cert).OCSPStaple != nil {
+		t.Fatal("expired staple served after responder failure")
+	}
+
+	responder.Set(ocsp.Good, false)
+	updateStaple(cert)
+	if withStaple(cert).OCSPStaple != nil {
+		t.Fatal("staple retried before backoff")
+	}
+
+	expireLastTry(cert, time.Time{}, time.Time{})
+	updateStaple(cert)
+	if withStaple(cert).OCSPStaple == nil {
+		t.Fatal("staple not recovered after responder recovery")
+	}
+}
+
+func TestOcspRevoked(t *testing.T) {
+	cert, responder := setupOcsp(t)
+
+	responder.Set(ocsp.Revoked, false)
+	updateStaple(cert)
+
+	if withStaple(cert).OCSPStaple != nil {
+		t.Fatal("revoked response stapled")
+	}
+}
+
+func setStore(t *testing.T, certList ...*tls.Certificate) {
+	certsLock.Lock()
+	prev := certs
+	certs = newStore()
+	certs.certs = certList
+	certsLock.Unlock()
+
+	t.Cleanup(func() {
+		certsLock.Lock()
+		certs = prev
+		certsLock.Unlock()
+	})
+}
+
+func TestOcspPrune(t *testing.T) {
+	cert, _ := setupOcsp(t)
+	other, _, _ := newOcspChain(t)
+
+	setStore(t, cert, other)
+	updateStaples()
+
+	if getStaple(cert) == nil || getStaple(other) == nil {
+		t.Fatal("staples missing after update")
+	}
+
+	metricsOutput := func() string {
+		resp := httptest.NewRecorder()
+		metrics.Handler().ServeHTTP(resp,
+			httptest.NewRequest("GET", "/metrics", nil))
+		return resp.Body.String()
+	}
+
+	output := metricsOutput()
+	for _, crt := range []*tls.Certificate{cert, other} {
+		if !strings.Contains(output, `id="`+leafId(crt)+`"`) {
+			t.Fatal("staple metric missing certificate id")
+		}
+	}
+
+	setStore(t, cert)
+	updateStaples()
+
+	if getStaple(other) != nil {
+		t.Fatal("staple of replaced certificate not pruned")
+	}
+	if getStaple(cert) == nil {
+		t.Fatal("staple of current certificate pruned")
+	}
+	if strings.Contains(metricsOutput(), `id="`+leafId(other)+`"`) {
+		t.Fatal("metric of replaced certificate not removed")
+	}
+}
+
+func TestOcspAcme(t *testing.T) {
+	cert, _ := setupOcsp(t)
+	setStore(t)
+
+	acmeCertsLock.Lock()
+	acmeCerts["web.example.com"] = cert
+	acmeCertsLock.Unlock()
+	t.Cleanup(func() {
+		acmeCertsLock.Lock()
+		acmeCerts = map[string]*tls.Certificate{}
+		acmeCertsLock.Unlock()
+	})
+
+	updateStaples()
+
+	if withStaple(cert).OCSPStaple == nil {
+		t.Fatal("ACME certificate not stapled")
+	}
+}
@@ -0,0 +1,231 @@
+package certificate
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-web/constants"
+	"github.com/pritunl/pritunl-web/errortypes"
+	"github.com/sirupsen/logrus"
+)
+
+type store struct {
+	names       map[string]*tls.Certificate
+	certs       []*tls.Certificate
+	sources     []string
+	defaultCert *tls.Certificate
+}
+
+func newStore() *store {
+	return &store{
+		names: map[string]*tls.Certificate{},
+	}
+}
+
+func (s *store) add(source string, certPem, keyPem []byte) (err error) {
+	tlsCert, err := tls.X509KeyPair(certPem, keyPem)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrapf(err, "certificate: Certificate '%s' does not "+
+				"match key", source),
+		}
+		return
+	}
+
+	if tlsCert.Leaf == nil {
+		tlsCert.Leaf, err = x509.ParseCertificate(tlsCert.Certificate[0])
+		if err != nil {
+			err = &errortypes.ParseError{
+				errors.Wrapf(err, "certificate: Failed to parse '%s'",
+					source),
+			}
+			return
+		}
+	}
+
+	names := tlsCert.Leaf.DNSNames
+	if len(names) == 0 && tlsCert.Leaf.Subject.CommonName != "" {
+		names = []string{tlsCert.Leaf.Subject.CommonName}
+	}
+
+	if time.Now().After(tlsCert.Leaf.NotAfter) {
+		logrus.WithFields(logrus.Fields{
+			"source":    source,
+			"names":     names,
+			"not_after": tlsCert.Leaf.NotAfter,
+		}).Warn("certificate: Loaded certificate is expired")
+	}
+
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSuffix(name, "."))
+		if _, ok := s.names[name]; !ok {
+			s.names[name] = &tlsCert
+		}
+	}
+	s.certs = append(s.certs, &tlsCert)
+	s.sources = append(s.sources, source)
+
+	logrus.WithFields(logrus.Fields{
+		"source": source,
+		"names":  names,
+	}).Info("certificate: Loaded certificate")
+
+	return
+}
+
+func (s *store) addEnv(source, certStr, keyStr string) (err error) {
+	certPem, err := base64.StdEncoding.DecodeString(certStr)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrapf(err, "certificate: Server cert '%s' decode error",
+				source),
+		}
+		return
+	}
+
+	keyPem, err := base64.StdEncoding.DecodeString(keyStr)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrapf(err, "certificate: Server key '%s' decode error",
+				source),
+		}
+		return
+	}
+
+	err = s.add(source, certPem, keyPem)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (s *store) addDir(dir string) (err error) {
+	certPaths, err := filepath.Glob(filepath.Join(dir, "*.crt"))
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "certificate: Failed to list certificate dir"),
+		}
+		return
+	}
+
+	for _, certPath := range certPaths {
+		keyPath := strings.TrimSuffix(certPath, ".crt") + ".key"
+
+		certPem, e := os.ReadFile(certPath)
+		if e != nil {
+			err = &errortypes.ReadError{
+				errors.Wrapf(e, "certificate: Failed to read '%s'", certPath),
+			}
+			return
+		}
+
+		keyPem, e := os.ReadFile(keyPath)
+		if e != nil {
+			err = &errortypes.ReadError{
+				errors.Wrapf(e, "certificate: Failed to read '%s'", keyPath),
+			}
+			return
+		}
+
+		err = s.add(certPath, certPem, keyPem)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (s *store) match(serverName string) *tls.Certificate {
+	serverName = strings.ToLower(strings.TrimSuffix(serverName, "."))
+	if serverName == "" {
+		return nil
+	}
+
+	if cert, ok := s.names[serverName]; ok {
+		return cert
+	}
+
+	labels := strings.SplitN(serverName, ".", 2)
+	if len(labels) == 2 {
+		if cert, ok := s.names["*."+labels[1]]; ok {
+			return cert
+		}
+	}
+
+	return nil
+}
+
+func (s *store) get(serverName string) *tls.Certificate {
+	cert := s.match(serverName)
+	if cert != nil {
+		return cert
+	}
+	return s.defaultCert
+}
+
+func buildStore() (certStore *store, err error) {
+	certStore = newStore()
+
+	if constants.SslCert != "" && constants.SslKey != "" {
+		err = certStore.addEnv("SSL_CERT", constants.SslCert,
+			constants.SslKey)
+		if err != nil {
+			return
+		}
+	}
+
+	for i := range constants.Certs.ExtraCerts {
+		err = certStore.addEnv(
+			"SSL_CERT_"+strconv.Itoa(i+1),
+			constants.Certs.ExtraCerts[i],
+			constants.Certs.ExtraKeys[i],
+		)
+		if err != nil {
+			return
+		}
+	}
+
+	if constants.Certs.Dir != "" {
+		err = certStore.addDir(constants.Certs.Dir)
+		if err != nil {
+			return
+		}
+	}
+
+	if constants.Certs.DefaultName != "" {
+		certStore.defaultCert = certStore.match(constants.Certs.DefaultName)
+		if certStore.defaultCert == nil {
+			err = &errortypes.ParseError{
+				errors.Newf("certificate: No certificate matches "+
+					"default name '%s'", constants.Certs.DefaultName),
+			}
+			return
+		}
+	} else if len(certStore.certs) > 0 {
+		certStore.defaultCert = certStore.certs[0]
+	}
+
+	return
+}
+
+func loadStore() (err error) {
+	certStore, err := buildStore()
+	if err != nil {
+		return
+	}
+
+	certsLock.Lock()
+	certs = certStore
+	certsLock.Unlock()
+
+	return
+}
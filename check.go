@@ -0,0 +1,251 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-web/certificate"
+	"github.com/pritunl/pritunl-web/config"
+	"github.com/pritunl/pritunl-web/constants"
+	"github.com/pritunl/pritunl-web/request"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	checkOk      = "ok"
+	checkWarning = "warning"
+	checkError   = "error"
+)
+
+type checkResult struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+type checkReport struct {
+	Ok      bool           `json:"ok"`
+	Checks  []*checkResult `json:"checks"`
+	subject string
+}
+
+func (r *checkReport) add(name, status, message string) {
+	r.Checks = append(r.Checks, &checkResult{
+		Name:    name,
+		Status:  status,
+		Message: message,
+	})
+	if status == checkError {
+		r.Ok = false
+	}
+}
+
+func (r *checkReport) Print(jsonOutput bool) {
+	if jsonOutput {
+		data, _ := json.MarshalIndent(r, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+
+	for _, result := range r.Checks {
+		fmt.Printf("%-8s %s: %s\n", strings.ToUpper(result.Status),
+			result.Name, result.Message)
+	}
+
+	subject := r.subject
+	if subject == "" {
+		subject = "Configuration check"
+	}
+
+	if r.Ok {
+		fmt.Println(subject + " passed")
+	} else {
+		fmt.Println(subject + " failed")
+	}
+}
+
+func checkAddress(addr string) (err error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return
+	}
+
+	portNum, err := strconv.Atoi(port)
+	if err != nil || portNum < 1 || portNum > 65535 {
+		err = fmt.Errorf("invalid port '%s'", port)
+		return
+	}
+
+	if host != "" && net.ParseIP(host) == nil {
+		_, err = net.LookupHost(host)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func checkCertificates(report *checkReport) {
+	if !constants.Ssl {
+		report.add("certificates", checkOk, "SSL disabled")
+		return
+	}
+
+	statuses, err := certificate.Check()
+	if err != nil {
+		report.add("certificates", checkError, errors.GetMessage(err))
+		return
+	}
+
+	if len(statuses) == 0 {
+		report.add("certificates", checkOk,
+			"No static certificates, using ACME")
+		return
+	}
+
+	now := time.Now()
+	for _, status := range statuses {
+		name := "certificate " + status.Source
+		names := strings.Join(status.Names, ",")
+
+		if now.Before(status.NotBefore) {
+			report.add(name, checkError, fmt.Sprintf(
+				"Certificate for %s not valid until %s", names,
+				status.NotBefore.Format(time.RFC3339)))
+		} else if now.After(status.NotAfter) {
+			report.add(name, checkError, fmt.Sprintf(
+				"Certificate for %s expired %s", names,
+				status.NotAfter.Format(time.RFC3339)))
+		} else if status.NotAfter.Sub(now) < 14*24*time.Hour {
+			report.add(name, checkWarning, fmt.Sprintf(
+				"Certificate for %s expires %s", names,
+				status.NotAfter.Format(time.RFC3339)))
+		} else {
+			report.add(name, checkOk, fmt.Sprintf(
+				"Certificate for %s valid until %s", names,
+				status.NotAfter.Format(time.RFC3339)))
+		}
+	}
+}
+
+func runChecks(probe bool) (report *checkReport) {
+	report = &checkReport{
+		Ok: true,
+	}
+
+	if constants.WebSecret.Load() == nil {
+		report.add("web_secret", checkWarning,
+			"Web secret not configured, admin sessions disabled")
+	} else if webSecretSize != 32 {
+		status := checkWarning
+		if probe {
+			status = checkError
+		}
+		report.add("web_secret", status, fmt.Sprintf(
+			"Web secret is %d bytes, must be 32 bytes", webSecretSize))
+	} else {
+		report.add("web_secret", checkOk, "Web secret is 32 bytes")
+	}
+
+	checkCertificates(report)
+
+	bindAddr := constants.BindHost + ":" + constants.BindPort
+	err := checkAddress(bindAddr)
+	if err != nil {
+		report.add("bind_address", checkError, fmt.Sprintf(
+			"Invalid bind address '%s': %s", bindAddr, err))
+	} else {
+		report.add("bind_address", checkOk, bindAddr)
+	}
+
+	if constants.MetricsAddress != "" {
+		err = checkAddress(constants.MetricsAddress)
+		if err != nil {
+			report.add("metrics_address", checkError, fmt.Sprintf(
+				"Invalid metrics address '%s': %s",
+				constants.MetricsAddress, errors.GetMessage(err)))
+		} else {
+			report.add("metrics_address", checkOk,
+				constants.MetricsAddress)
+		}
+	}
+
+	if probe {
+		err = request.Init()
+		if err == nil {
+			err = request.Check(10 * time.Second)
+		}
+		if err != nil {
+			report.add("internal_address", checkError, fmt.Sprintf(
+				"Backend '%s' unreachable: %s",
+				constants.InternalHost, errors.GetMessage(err)))
+		} else {
+			report.add("internal_address", checkOk, fmt.Sprintf(
+				"Backend '%s' reachable", constants.InternalHost))
+		}
+	}
+
+	return
+}
+
+func checkCommand(configPath string, args []string) int {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	flags.StringVar(&configPath, "config", configPath,
+		"Path to configuration file")
+	jsonOutput := flags.Bool("json", false, "Print report as JSON")
+	flags.Parse(args)
+
+	logrus.SetLevel(logrus.ErrorLevel)
+
+	report := &checkReport{
+		Ok: true,
+	}
+
+	conf, err := config.Load(configPath)
+	if err == nil {
+		err = applyConfig(conf)
+	}
+	if err != nil {
+		report.add("config", checkError, errors.GetMessage(err))
+		report.Print(*jsonOutput)
+		return 1
+	}
+	report.add("config", checkOk, "Configuration loaded")
+
+	checks := runChecks(true)
+	report.Checks = append(report.Checks, checks.Checks...)
+	report.Ok = checks.Ok
+
+	report.Print(*jsonOutput)
+	if !report.Ok {
+		return 1
+	}
+	return 0
+}
+
+func selfTest() (ok bool) {
+	report := runChecks(false)
+
+	for _, result := range report.Checks {
+		fields := logrus.Fields{
+			"check":   result.Name,
+			"message": result.Message,
+		}
+
+		switch result.Status {
+		case checkError:
+			logrus.WithFields(fields).Error("main: Self test failed")
+		case checkWarning:
+			logrus.WithFields(fields).Warn("main: Self test warning")
+		}
+	}
+
+	return report.Ok
+}
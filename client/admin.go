@@ -0,0 +1,49 @@
+package client
+
+import (
+	"github.com/pritunl/pritunl-web/payload"
+)
+
+type Admin struct {
+	Id        string `json:"id"`
+	Username  string `json:"username"`
+	YubikeyId string `json:"yubikey_id"`
+	OtpAuth   bool   `json:"otp_auth"`
+	AuthApi   bool   `json:"auth_api"`
+	Token     string `json:"token"`
+	Secret    string `json:"secret"`
+	Disabled  bool   `json:"disabled"`
+	SuperUser bool   `json:"super_user"`
+}
+
+func (c *Client) Admins() (admins []*Admin, err error) {
+	err = c.Do("GET", "/admin", nil, nil, &admins)
+	return
+}
+
+func (c *Client) Admin(adminId string) (admin *Admin, err error) {
+	admin = &Admin{}
+	err = c.Do("GET", apiPath("admin", adminId), nil, nil, admin)
+	return
+}
+
+func (c *Client) CreateAdmin(data *payload.AdminPost) (
+	admin *Admin, err error) {
+
+	admin = &Admin{}
+	err = c.Do("POST", "/admin", nil, data, admin)
+	return
+}
+
+func (c *Client) UpdateAdmin(adminId string, data *payload.AdminPut) (
+	admin *Admin, err error) {
+
+	admin = &Admin{}
+	err = c.Do("PUT", apiPath("admin", adminId), nil, data, admin)
+	return
+}
+
+func (c *Client) DeleteAdmin(adminId string) (err error) {
+	err = c.Do("DELETE", apiPath("admin", adminId), nil, nil, nil)
+	return
+}
@@ -0,0 +1,220 @@
+package client
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-web/errortypes"
+)
+
+type StatusError struct {
+	errors.DropboxError
+	Status int
+}
+
+type Client struct {
+	BaseUrl    string
+	Token      string
+	Secret     string
+	HttpClient *http.Client
+}
+
+func New(baseUrl, token, secret string) *Client {
+	return &Client{
+		BaseUrl: baseUrl,
+		Token:   token,
+		Secret:  secret,
+		HttpClient: &http.Client{
+			Timeout: 2 * time.Minute,
+		},
+	}
+}
+
+func apiPath(elems ...string) (path string) {
+	for _, elem := range elems {
+		path += "/" + url.PathEscape(elem)
+	}
+	return
+}
+
+func signature(secret, token, timestamp, nonce, method,
+	path string) string {
+
+	hash := hmac.New(sha256.New, []byte(secret))
+	hash.Write([]byte(strings.Join([]string{
+		token,
+		timestamp,
+		nonce,
+		strings.ToUpper(method),
+		path,
+	}, "&")))
+
+	return base64.StdEncoding.EncodeToString(hash.Sum(nil))
+}
+
+func (c *Client) sign(req *http.Request) (err error) {
+	nonceByt := make([]byte, 16)
+	_, err = rand.Read(nonceByt)
+	if err != nil {
+		err = &errortypes.UnknownError{
+			errors.Wrap(err, "client: Failed to generate nonce"),
+		}
+		return
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := hex.EncodeToString(nonceByt)
+
+	req.Header.Set("Auth-Token", c.Token)
+	req.Header.Set("Auth-Timestamp", timestamp)
+	req.Header.Set("Auth-Nonce", nonce)
+	req.Header.Set("Auth-Signature", signature(c.Secret, c.Token,
+		timestamp, nonce, req.Method, req.URL.Path))
+
+	return
+}
+
+func (c *Client) Do(method, path string, query url.Values,
+	input, output interface{}) (err error) {
+
+	reqUrl, err := url.Parse(c.BaseUrl)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrap(err, "client: Failed to parse base URL"),
+		}
+		return
+	}
+	reqUrl.RawPath = strings.TrimSuffix(reqUrl.EscapedPath(), "/") + path
+	reqUrl.Path, err = url.PathUnescape(reqUrl.RawPath)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrap(err, "client: Failed to parse request path"),
+		}
+		return
+	}
+	reqUrl.RawQuery = query.Encode()
+
+	var body io.Reader
+	if input != nil {
+		data, e := json.Marshal(input)
+		if e != nil {
+			err = &errortypes.ParseError{
+				errors.Wrap(e, "client: Failed to marshal request"),
+			}
+			return
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, reqUrl.String(), body)
+	if err != nil {
+		err = &errortypes.RequestError{
+			errors.Wrap(err, "client: Failed to create request"),
+		}
+		return
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if input != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	err = c.sign(req)
+	if err != nil {
+		return
+	}
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		err = &errortypes.RequestError{
+			errors.Wrap(err, "client: Request failed"),
+		}
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		err = &StatusError{
+			errors.Newf("client: Request %s %s failed with status %d: %s",
+				method, path, resp.StatusCode,
+				strings.TrimSpace(string(msg))),
+			resp.StatusCode,
+		}
+		return
+	}
+
+	if output == nil {
+		return
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(output)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrap(err, "client: Failed to parse response"),
+		}
+		return
+	}
+
+	return
+}
+
+func (c *Client) paginate(path, key string, query url.Values,
+	handle func(data json.RawMessage) error) (err error) {
+
+	if query == nil {
+		query = url.Values{}
+	}
+
+	for page := 0; ; page++ {
+		query.Set("page", strconv.Itoa(page))
+
+		resp := map[string]json.RawMessage{}
+		err = c.Do("GET", path, query, nil, &resp)
+		if err != nil {
+			return
+		}
+
+		pageTotal := 0
+		items := []json.RawMessage{}
+
+		err = json.Unmarshal(resp[key], &items)
+		if err == nil && resp["page_total"] != nil {
+			err = json.Unmarshal(resp["page_total"], &pageTotal)
+		}
+		if err != nil {
+			err = &errortypes.ParseError{
+				errors.Wrap(err, "client: Failed to parse page"),
+			}
+			return
+		}
+
+		for _, item := range items {
+			err = handle(item)
+			if err != nil {
+				err = &errortypes.ParseError{
+					errors.Wrap(err, "client: Failed to parse page item"),
+				}
+				return
+			}
+		}
+
+		if len(items) == 0 || page >= pageTotal {
+			break
+		}
+	}
+
+	return
+}
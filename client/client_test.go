@@ -0,0 +1,573 @@
+package client_test
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-web/client"
+	"github.com/pritunl/pritunl-web/constants"
+	"github.com/pritunl/pritunl-web/fakebackend"
+	"github.com/pritunl/pritunl-web/handlers"
+	"github.com/pritunl/pritunl-web/payload"
+	"github.com/pritunl/pritunl-web/request"
+)
+
+const (
+	apiToken  = "test-token"
+	apiSecret = "test-secret"
+)
+
+var pageKeys = map[string]string{
+	"/server":       "servers",
+	"/organization": "organizations",
+	"/user/org1":    "users",
+	"/host":         "hosts",
+	"/link":         "links",
+}
+
+func pageResponse(path, page string) string {
+	pageNum, _ := strconv.Atoi(page)
+
+	items := []string{}
+	if pageNum <= 2 {
+		for i := 0; i < 2; i++ {
+			items = append(items, fmt.Sprintf(`{"id":"%d-%d"}`, pageNum, i))
+		}
+	}
+
+	return fmt.Sprintf(`{"page":%d,"page_total":2,"%s":[%s]}`,
+		pageNum, pageKeys[path], strings.Join(items, ","))
+}
+
+func newTestServer(t *testing.T) (web *httptest.Server,
+	backend *fakebackend.Backend) {
+
+	gin.SetMode(gin.TestMode)
+
+	responses := map[string]string{
+		"GET /server/srv1/organization": "[]",
+		"GET /server/srv1/host":         "[]",
+		"GET /server/srv1/link":         "[]",
+		"GET /server/srv1/route":        "[]",
+		"POST /server/srv1/routes":      "[]",
+		"POST /user/org1/multi":         "[]",
+		"GET /device/unregistered":      "[]",
+		"GET /admin":                    "[]",
+		"GET /link/lnk1/location":       "[]",
+	}
+
+	backend = fakebackend.New()
+	backend.Respond = func(w http.ResponseWriter,
+		req *fakebackend.Request, index int) {
+
+		resp, ok := responses[req.Method+" "+req.Path]
+		if page := req.Query.Get("page"); page != "" {
+			resp = pageResponse(req.Path, page)
+		} else if !ok {
+			resp = "{}"
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(resp))
+	}
+
+	backendServer := httptest.NewServer(backend)
+	t.Cleanup(backendServer.Close)
+
+	secretsPath := filepath.Join(t.TempDir(), "api_secrets.json")
+	err := os.WriteFile(secretsPath, []byte(
+		fmt.Sprintf(`{"%s":"%s"}`, apiToken, apiSecret)), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret := &[32]byte{}
+	rand.Read(secret[:])
+	constants.WebSecret.Store(secret)
+	constants.WebStrict = false
+	constants.InternalHost = backendServer.Listener.Addr().String()
+	constants.Scheme = "http"
+	constants.Api.AuthWindow = 300
+	constants.Api.NonceCacheSize = 1000
+	constants.Api.SecretsPath = secretsPath
+
+	err = request.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = handlers.LoadApiSecrets()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	handlers.Register(router)
+
+	web = httptest.NewServer(router)
+	t.Cleanup(web.Close)
+
+	return
+}
+
+func TestSignature(t *testing.T) {
+	web, backend := newTestServer(t)
+
+	clnt := client.New(web.URL, apiToken, apiSecret)
+	_, err := clnt.Server("srv1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reqs := backend.Requests()
+	if len(reqs) != 1 {
+		t.Fatalf("expected 1 backend request, got %d", len(reqs))
+	}
+
+	for _, key := range []string{
+		"Auth-Token",
+		"Auth-Timestamp",
+		"Auth-Nonce",
+		"Auth-Signature",
+	} {
+		if reqs[0].Header.Get(key) == "" {
+			t.Errorf("header %s not forwarded", key)
+		}
+	}
+
+	backend.Reset()
+
+	clnt = client.New(web.URL, apiToken, "wrong-secret")
+	_, err = clnt.Server("srv1")
+
+	statusErr, ok := err.(*client.StatusError)
+	if !ok {
+		t.Fatalf("expected status error, got %v", err)
+	}
+	if statusErr.Status != 401 {
+		t.Errorf("status %d, expected 401", statusErr.Status)
+	}
+
+	if len(backend.Requests()) != 0 {
+		t.Error("request with invalid signature reached backend")
+	}
+}
+
+func TestPathEscape(t *testing.T) {
+	var uris []string
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			uris = append(uris, r.RequestURI)
+			w.Write([]byte("{}"))
+		}))
+	t.Cleanup(server.Close)
+
+	clnt := client.New(server.URL+"/api/", apiToken, apiSecret)
+
+	_, err := clnt.Server("srv/1?x")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = clnt.AttachServerOrg("srv 1", "org%1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"/api/server/srv%2F1%3Fx",
+		"/api/server/srv%201/organization/org%251",
+	}
+	if strings.Join(uris, " ") != strings.Join(expected, " ") {
+		t.Fatalf("request uris %v, expected %v", uris, expected)
+	}
+}
+
+func TestPagination(t *testing.T) {
+	web, backend := newTestServer(t)
+	clnt := client.New(web.URL, apiToken, apiSecret)
+
+	ids := func(count int, getId func(i int) string) (ids []string) {
+		for i := 0; i < count; i++ {
+			ids = append(ids, getId(i))
+		}
+		return
+	}
+	expected := "0-0,0-1,1-0,1-1,2-0,2-1"
+
+	tests := []struct {
+		name string
+		path string
+		list func() ([]string, error)
+	}{
+		{"servers", "/server", func() ([]string, error) {
+			items, err := clnt.Servers()
+			return ids(len(items), func(i int) string {
+				return items[i].Id
+			}), err
+		}},
+		{"orgs", "/organization", func() ([]string, error) {
+			items, err := clnt.Orgs()
+			return ids(len(items), func(i int) string {
+				return items[i].Id
+			}), err
+		}},
+		{"users", "/user/org1", func() ([]string, error) {
+			items, err := clnt.Users("org1", "name")
+			return ids(len(items), func(i int) string {
+				return items[i].Id
+			}), err
+		}},
+		{"hosts", "/host", func() ([]string, error) {
+			items, err := clnt.Hosts()
+			return ids(len(items), func(i int) string {
+				return items[i].Id
+			}), err
+		}},
+		{"links", "/link", func() ([]string, error) {
+			items, err := clnt.Links()
+			return ids(len(items), func(i int) string {
+				return items[i].Id
+			}), err
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backend.Reset()
+
+			items, err := test.list()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if strings.Join(items, ",") != expected {
+				t.Errorf("items %v, expected %s", items, expected)
+			}
+
+			reqs := backend.Requests()
+			if len(reqs) != 3 {
+				t.Fatalf("expected 3 page requests, got %d", len(reqs))
+			}
+
+			for i, req := range reqs {
+				if req.Path != test.path {
+					t.Errorf("path %s, expected %s", req.Path, test.path)
+				}
+
+				if !strings.Contains(req.RawQuery, fmt.Sprintf("page=%d", i)) {
+					t.Errorf("query %s missing page %d", req.RawQuery, i)
+				}
+
+				if test.name == "users" &&
+					!strings.Contains(req.RawQuery, "search=name") {
+
+					t.Errorf("query %s missing search", req.RawQuery)
+				}
+			}
+		})
+	}
+}
+
+func TestResources(t *testing.T) {
+	web, backend := newTestServer(t)
+	clnt := client.New(web.URL, apiToken, apiSecret)
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		call   func() error
+	}{
+		{"GET", "/server/srv1", "", func() (err error) {
+			_, err = clnt.Server("srv1")
+			return
+		}},
+		{"POST", "/server", `"name":"vpn"`, func() (err error) {
+			_, err = clnt.CreateServer(&payload.Server{Name: "vpn"})
+			return
+		}},
+		{"PUT", "/server/srv1", `"port":1194`, func() (err error) {
+			_, err = clnt.UpdateServer("srv1", &payload.Server{Port: 1194})
+			return
+		}},
+		{"DELETE", "/server/srv1", "", func() error {
+			return clnt.DeleteServer("srv1")
+		}},
+		{"PUT", "/server/srv1/operation/start", "", func() (err error) {
+			_, err = clnt.ServerOperation("srv1", "start")
+			return
+		}},
+		{"GET", "/server/srv1/organization", "", func() (err error) {
+			_, err = clnt.ServerOrgs("srv1")
+			return
+		}},
+		{"PUT", "/server/srv1/organization/org1", "", func() error {
+			return clnt.AttachServerOrg("srv1", "org1")
+		}},
+		{"DELETE", "/server/srv1/organization/org1", "", func() error {
+			return clnt.DetachServerOrg("srv1", "org1")
+		}},
+		{"GET", "/server/srv1/host", "", func() (err error) {
+			_, err = clnt.ServerHosts("srv1")
+			return
+		}},
+		{"PUT", "/server/srv1/host/hst1", "", func() error {
+			return clnt.AttachServerHost("srv1", "hst1")
+		}},
+		{"DELETE", "/server/srv1/host/hst1", "", func() error {
+			return clnt.DetachServerHost("srv1", "hst1")
+		}},
+		{"GET", "/server/srv1/link", "", func() (err error) {
+			_, err = clnt.ServerLinks("srv1")
+			return
+		}},
+		{"PUT", "/server/srv1/link/lnk1", `"use_local_address":true`,
+			func() error {
+				return clnt.AttachServerLink("srv1", "lnk1",
+					&payload.ServerLink{UseLocalAddress: true})
+			}},
+		{"DELETE", "/server/srv1/link/lnk1", "", func() error {
+			return clnt.DetachServerLink("srv1", "lnk1")
+		}},
+		{"GET", "/server/srv1/route", "", func() (err error) {
+			_, err = clnt.Routes("srv1")
+			return
+		}},
+		{"POST", "/server/srv1/route", `"network":"10.0.0.0/8"`,
+			func() (err error) {
+				_, err = clnt.CreateRoute("srv1",
+					&payload.ServerRoute{Network: "10.0.0.0/8"})
+				return
+			}},
+		{"POST", "/server/srv1/routes", `[{"network":"10.0.0.0/8"`,
+			func() (err error) {
+				_, err = clnt.CreateRoutes("srv1", []*payload.ServerRoute{
+					{Network: "10.0.0.0/8"},
+				})
+				return
+			}},
+		{"PUT", "/server/srv1/route/rt1", `"nat":true`, func() (err error) {
+			_, err = clnt.UpdateRoute("srv1", "rt1",
+				&payload.ServerRoute{Nat: true})
+			return
+		}},
+		{"DELETE", "/server/srv1/route/rt1", "", func() error {
+			return clnt.DeleteRoute("srv1", "rt1")
+		}},
+		{"GET", "/organization/org1", "", func() (err error) {
+			_, err = clnt.Org("org1")
+			return
+		}},
+		{"POST", "/organization", `"name":"org"`, func() (err error) {
+			_, err = clnt.CreateOrg(&payload.OrgPost{Name: "org"})
+			return
+		}},
+		{"PUT", "/organization/org1", `"auth_token":true`,
+			func() (err error) {
+				_, err = clnt.UpdateOrg("org1",
+					&payload.OrgPut{AuthToken: true})
+				return
+			}},
+		{"DELETE", "/organization/org1", "", func() error {
+			return clnt.DeleteOrg("org1")
+		}},
+		{"GET", "/user/org1/usr1", "", func() (err error) {
+			_, err = clnt.User("org1", "usr1")
+			return
+		}},
+		{"POST", "/user/org1", `"email":"a@b.c"`, func() (err error) {
+			_, err = clnt.CreateUser("org1",
+				&payload.UserPost{Email: "a@b.c"})
+			return
+		}},
+		{"POST", "/user/org1/multi", `[{"name":"a"`, func() (err error) {
+			_, err = clnt.CreateUsers("org1", []*payload.UserPost{
+				{Name: "a"},
+			})
+			return
+		}},
+		{"PUT", "/user/org1/usr1", `"disabled":true`, func() (err error) {
+			_, err = clnt.UpdateUser("org1", "usr1",
+				&payload.UserPut{Disabled: true})
+			return
+		}},
+		{"DELETE", "/user/org1/usr1", "", func() error {
+			return clnt.DeleteUser("org1", "usr1")
+		}},
+		{"PUT", "/user/org1/usr1/otp_secret", "", func() (err error) {
+			_, err = clnt.ResetUserOtp("org1", "usr1")
+			return
+		}},
+		{"GET", "/device/unregistered", "", func() (err error) {
+			_, err = clnt.UnregisteredDevices()
+			return
+		}},
+		{"PUT", "/device/register/org1/usr1/dev1", `"reg_key":"ABC"`,
+			func() error {
+				return clnt.RegisterDevice("org1", "usr1", "dev1",
+					&payload.DeviceRegister{RegKey: "ABC"})
+			}},
+		{"DELETE", "/device/register/org1/usr1/dev1", "", func() error {
+			return clnt.DeleteUnregisteredDevice("org1", "usr1", "dev1")
+		}},
+		{"PUT", "/user/org1/usr1/device/dev1", `"name":"phone"`,
+			func() error {
+				return clnt.UpdateUserDevice("org1", "usr1", "dev1",
+					&payload.UserDevice{Name: "phone"})
+			}},
+		{"DELETE", "/user/org1/usr1/device/dev1", "", func() error {
+			return clnt.DeleteUserDevice("org1", "usr1", "dev1")
+		}},
+		{"GET", "/host/hst1", "", func() (err error) {
+			_, err = clnt.Host("hst1")
+			return
+		}},
+		{"PUT", "/host/hst1", `"priority":5`, func() (err error) {
+			_, err = clnt.UpdateHost("hst1", &payload.Host{Priority: 5})
+			return
+		}},
+		{"DELETE", "/host/hst1", "", func() error {
+			return clnt.DeleteHost("hst1")
+		}},
+		{"POST", "/link", `"type":"site_to_site"`, func() (err error) {
+			_, err = clnt.CreateLink(&payload.LinkPost{Type: "site_to_site"})
+			return
+		}},
+		{"PUT", "/link/lnk1", `"key":true`, func() (err error) {
+			_, err = clnt.UpdateLink("lnk1", &payload.LinkPut{Key: true})
+			return
+		}},
+		{"DELETE", "/link/lnk1", "", func() error {
+			return clnt.DeleteLink("lnk1")
+		}},
+		{"GET", "/link/lnk1/location", "", func() (err error) {
+			_, err = clnt.LinkLocations("lnk1")
+			return
+		}},
+		{"POST", "/link/lnk1/location", `"name":"east"`, func() (err error) {
+			_, err = clnt.CreateLinkLocation("lnk1",
+				&payload.LinkLocationPost{Name: "east"})
+			return
+		}},
+		{"PUT", "/link/lnk1/location/loc1", `"name":"west"`,
+			func() (err error) {
+				_, err = clnt.UpdateLinkLocation("lnk1", "loc1",
+					&payload.LinkLocationPut{Name: "west"})
+				return
+			}},
+		{"DELETE", "/link/lnk1/location/loc1", "", func() error {
+			return clnt.DeleteLinkLocation("lnk1", "loc1")
+		}},
+		{"POST", "/link/lnk1/location/loc1/route",
+			`"network":"10.1.0.0/16"`, func() error {
+				return clnt.CreateLinkRoute("lnk1", "loc1",
+					&payload.LinkLocationRoutePost{Network: "10.1.0.0/16"})
+			}},
+		{"PUT", "/link/lnk1/location/loc1/route/rt1",
+			`"network":"10.2.0.0/16"`, func() error {
+				return clnt.UpdateLinkRoute("lnk1", "loc1", "rt1",
+					&payload.LinkLocationRoutePut{Network: "10.2.0.0/16"})
+			}},
+		{"DELETE", "/link/lnk1/location/loc1/route/rt1", "", func() error {
+			return clnt.DeleteLinkRoute("lnk1", "loc1", "rt1")
+		}},
+		{"POST", "/link/lnk1/location/loc1/host", `"timeout":10`,
+			func() (err error) {
+				_, err = clnt.CreateLinkHost("lnk1", "loc1",
+					&payload.LinkLocationHostPost{Timeout: 10})
+				return
+			}},
+		{"PUT", "/link/lnk1/location/loc1/host/hst1", `"static":true`,
+			func() (err error) {
+				_, err = clnt.UpdateLinkHost("lnk1", "loc1", "hst1",
+					&payload.LinkLocationHostPut{Static: true})
+				return
+			}},
+		{"DELETE", "/link/lnk1/location/loc1/host/hst1", "", func() error {
+			return clnt.DeleteLinkHost("lnk1", "loc1", "hst1")
+		}},
+		{"GET", "/link/lnk1/location/loc1/host/hst1/uri", "",
+			func() (err error) {
+				_, err = clnt.LinkHostUri("lnk1", "loc1", "hst1")
+				return
+			}},
+		{"POST", "/link/lnk1/location/loc1/peer", `"peer_id":"loc2"`,
+			func() error {
+				return clnt.AddLinkPeer("lnk1", "loc1", "loc2")
+			}},
+		{"DELETE", "/link/lnk1/location/loc1/peer/loc2", "", func() error {
+			return clnt.RemoveLinkPeer("lnk1", "loc1", "loc2")
+		}},
+		{"POST", "/link/lnk1/location/loc1/transit", `"transit_id":"loc3"`,
+			func() error {
+				return clnt.AddLinkTransit("lnk1", "loc1", "loc3")
+			}},
+		{"DELETE", "/link/lnk1/location/loc1/transit/loc3", "",
+			func() error {
+				return clnt.RemoveLinkTransit("lnk1", "loc1", "loc3")
+			}},
+		{"GET", "/settings", "", func() (err error) {
+			_, err = clnt.Settings()
+			return
+		}},
+		{"PUT", "/settings", `"theme":"dark"`, func() (err error) {
+			_, err = clnt.UpdateSettings(&payload.Settings{Theme: "dark"})
+			return
+		}},
+		{"GET", "/admin", "", func() (err error) {
+			_, err = clnt.Admins()
+			return
+		}},
+		{"GET", "/admin/adm1", "", func() (err error) {
+			_, err = clnt.Admin("adm1")
+			return
+		}},
+		{"POST", "/admin", `"username":"ops"`, func() (err error) {
+			_, err = clnt.CreateAdmin(&payload.AdminPost{Username: "ops"})
+			return
+		}},
+		{"PUT", "/admin/adm1", `"super_user":true`, func() (err error) {
+			_, err = clnt.UpdateAdmin("adm1",
+				&payload.AdminPut{SuperUser: true})
+			return
+		}},
+		{"DELETE", "/admin/adm1", "", func() error {
+			return clnt.DeleteAdmin("adm1")
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.method+" "+test.path, func(t *testing.T) {
+			backend.Reset()
+
+			err := test.call()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			reqs := backend.Requests()
+			if len(reqs) != 1 {
+				t.Fatalf("expected 1 backend request, got %d", len(reqs))
+			}
+
+			if reqs[0].Method != test.method || reqs[0].Path != test.path {
+				t.Errorf("backend request %s %s, expected %s %s",
+					reqs[0].Method, reqs[0].Path, test.method, test.path)
+			}
+
+			if !strings.Contains(string(reqs[0].Body), test.body) {
+				t.Errorf("backend body %s missing %s",
+					reqs[0].Body, test.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package client
+
+import (
+	"github.com/pritunl/pritunl-web/payload"
+)
+
+type Device struct {
+	payload.DeviceRegister
+	Id     string `json:"id"`
+	UserId string `json:"user_id"`
+	OrgId  string `json:"org_id"`
+}
+
+func (c *Client) UnregisteredDevices() (devices []*Device, err error) {
+	err = c.Do("GET", "/device/unregistered", nil, nil, &devices)
+	return
+}
+
+func (c *Client) RegisterDevice(orgId, userId, deviceId string,
+	data *payload.DeviceRegister) (err error) {
+
+	err = c.Do("PUT", apiPath("device", "register", orgId, userId, deviceId),
+		nil, data, nil)
+	return
+}
+
+func (c *Client) DeleteUnregisteredDevice(orgId, userId,
+	deviceId string) (err error) {
+
+	err = c.Do("DELETE",
+		apiPath("device", "register", orgId, userId, deviceId),
+		nil, nil, nil)
+	return
+}
+
+func (c *Client) UpdateUserDevice(orgId, userId, deviceId string,
+	data *payload.UserDevice) (err error) {
+
+	err = c.Do("PUT", apiPath("user", orgId, userId, "device", deviceId),
+		nil, data, nil)
+	return
+}
+
+func (c *Client) DeleteUserDevice(orgId, userId,
+	deviceId string) (err error) {
+
+	err = c.Do("DELETE", apiPath("user", orgId, userId, "device", deviceId),
+		nil, nil, nil)
+	return
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"encoding/json"
+
+	"github.com/pritunl/pritunl-web/payload"
+)
+
+type Host struct {
+	payload.Host
+	Id     string `json:"id"`
+	Status string `json:"status"`
+}
+
+func (c *Client) Hosts() (hosts []*Host, err error) {
+	err = c.paginate("/host", "hosts", nil,
+		func(data json.RawMessage) error {
+			host := &Host{}
+			hosts = append(hosts, host)
+			return json.Unmarshal(data, host)
+		})
+	return
+}
+
+func (c *Client) Host(hostId string) (host *Host, err error) {
+	host = &Host{}
+	err = c.Do("GET", apiPath("host", hostId), nil, nil, host)
+	return
+}
+
+func (c *Client) UpdateHost(hostId string, data *payload.Host) (
+	host *Host, err error) {
+
+	host = &Host{}
+	err = c.Do("PUT", apiPath("host", hostId), nil, data, host)
+	return
+}
+
+func (c *Client) DeleteHost(hostId string) (err error) {
+	err = c.Do("DELETE", apiPath("host", hostId), nil, nil, nil)
+	return
+}
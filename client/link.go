@@ -0,0 +1,193 @@
+package client
+
+import (
+	"encoding/json"
+
+	"github.com/pritunl/pritunl-web/payload"
+)
+
+type Link struct {
+	payload.LinkPost
+	Id string `json:"id"`
+}
+
+type LinkLocation struct {
+	payload.LinkLocationPost
+	Id string `json:"id"`
+}
+
+type LinkHost struct {
+	payload.LinkLocationHostPost
+	Id       string `json:"id"`
+	Location string `json:"location"`
+}
+
+type LinkHostUri struct {
+	Uri string `json:"uri"`
+}
+
+func linkLocationPath(linkId, locationId string) string {
+	return apiPath("link", linkId, "location", locationId)
+}
+
+func (c *Client) Links() (links []*Link, err error) {
+	err = c.paginate("/link", "links", nil,
+		func(data json.RawMessage) error {
+			link := &Link{}
+			links = append(links, link)
+			return json.Unmarshal(data, link)
+		})
+	return
+}
+
+func (c *Client) CreateLink(data *payload.LinkPost) (link *Link, err error) {
+	link = &Link{}
+	err = c.Do("POST", "/link", nil, data, link)
+	return
+}
+
+func (c *Client) UpdateLink(linkId string, data *payload.LinkPut) (
+	link *Link, err error) {
+
+	link = &Link{}
+	err = c.Do("PUT", apiPath("link", linkId), nil, data, link)
+	return
+}
+
+func (c *Client) DeleteLink(linkId string) (err error) {
+	err = c.Do("DELETE", apiPath("link", linkId), nil, nil, nil)
+	return
+}
+
+func (c *Client) LinkLocations(linkId string) (
+	locations []*LinkLocation, err error) {
+
+	err = c.Do("GET", apiPath("link", linkId, "location"),
+		nil, nil, &locations)
+	return
+}
+
+func (c *Client) CreateLinkLocation(linkId string,
+	data *payload.LinkLocationPost) (location *LinkLocation, err error) {
+
+	location = &LinkLocation{}
+	err = c.Do("POST", apiPath("link", linkId, "location"),
+		nil, data, location)
+	return
+}
+
+func (c *Client) UpdateLinkLocation(linkId, locationId string,
+	data *payload.LinkLocationPut) (location *LinkLocation, err error) {
+
+	location = &LinkLocation{}
+	err = c.Do("PUT", linkLocationPath(linkId, locationId),
+		nil, data, location)
+	return
+}
+
+func (c *Client) DeleteLinkLocation(linkId, locationId string) (
+	err error) {
+
+	err = c.Do("DELETE", linkLocationPath(linkId, locationId),
+		nil, nil, nil)
+	return
+}
+
+func (c *Client) CreateLinkRoute(linkId, locationId string,
+	data *payload.LinkLocationRoutePost) (err error) {
+
+	err = c.Do("POST", linkLocationPath(linkId, locationId)+"/route",
+		nil, data, nil)
+	return
+}
+
+func (c *Client) UpdateLinkRoute(linkId, locationId, routeId string,
+	data *payload.LinkLocationRoutePut) (err error) {
+
+	err = c.Do("PUT",
+		linkLocationPath(linkId, locationId)+apiPath("route", routeId),
+		nil, data, nil)
+	return
+}
+
+func (c *Client) DeleteLinkRoute(linkId, locationId, routeId string) (
+	err error) {
+
+	err = c.Do("DELETE",
+		linkLocationPath(linkId, locationId)+apiPath("route", routeId),
+		nil, nil, nil)
+	return
+}
+
+func (c *Client) CreateLinkHost(linkId, locationId string,
+	data *payload.LinkLocationHostPost) (host *LinkHost, err error) {
+
+	host = &LinkHost{}
+	err = c.Do("POST", linkLocationPath(linkId, locationId)+"/host",
+		nil, data, host)
+	return
+}
+
+func (c *Client) UpdateLinkHost(linkId, locationId, hostId string,
+	data *payload.LinkLocationHostPut) (host *LinkHost, err error) {
+
+	host = &LinkHost{}
+	err = c.Do("PUT",
+		linkLocationPath(linkId, locationId)+apiPath("host", hostId),
+		nil, data, host)
+	return
+}
+
+func (c *Client) DeleteLinkHost(linkId, locationId, hostId string) (
+	err error) {
+
+	err = c.Do("DELETE",
+		linkLocationPath(linkId, locationId)+apiPath("host", hostId),
+		nil, nil, nil)
+	return
+}
+
+func (c *Client) LinkHostUri(linkId, locationId, hostId string) (
+	uri string, err error) {
+
+	data := &LinkHostUri{}
+	err = c.Do("GET",
+		linkLocationPath(linkId, locationId)+apiPath("host", hostId, "uri"),
+		nil, nil, data)
+	uri = data.Uri
+	return
+}
+
+func (c *Client) AddLinkPeer(linkId, locationId, peerId string) (
+	err error) {
+
+	err = c.Do("POST", linkLocationPath(linkId, locationId)+"/peer",
+		nil, &payload.LinkLocationPeer{PeerId: peerId}, nil)
+	return
+}
+
+func (c *Client) RemoveLinkPeer(linkId, locationId, peerId string) (
+	err error) {
+
+	err = c.Do("DELETE",
+		linkLocationPath(linkId, locationId)+apiPath("peer", peerId),
+		nil, nil, nil)
+	return
+}
+
+func (c *Client) AddLinkTransit(linkId, locationId, transitId string) (
+	err error) {
+
+	err = c.Do("POST", linkLocationPath(linkId, locationId)+"/transit",
+		nil, &payload.LinkLocationTransit{TransitId: transitId}, nil)
+	return
+}
+
+func (c *Client) RemoveLinkTransit(linkId, locationId,
+	transitId string) (err error) {
+
+	err = c.Do("DELETE",
+		linkLocationPath(linkId, locationId)+apiPath("transit", transitId),
+		nil, nil, nil)
+	return
+}
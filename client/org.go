@@ -0,0 +1,50 @@
+package client
+
+import (
+	"encoding/json"
+
+	"github.com/pritunl/pritunl-web/payload"
+)
+
+type Org struct {
+	payload.OrgPost
+	Id         string `json:"id"`
+	AuthToken  string `json:"auth_token"`
+	AuthSecret string `json:"auth_secret"`
+	UserCount  int    `json:"user_count"`
+}
+
+func (c *Client) Orgs() (orgs []*Org, err error) {
+	err = c.paginate("/organization", "organizations", nil,
+		func(data json.RawMessage) error {
+			org := &Org{}
+			orgs = append(orgs, org)
+			return json.Unmarshal(data, org)
+		})
+	return
+}
+
+func (c *Client) Org(orgId string) (org *Org, err error) {
+	org = &Org{}
+	err = c.Do("GET", apiPath("organization", orgId), nil, nil, org)
+	return
+}
+
+func (c *Client) CreateOrg(data *payload.OrgPost) (org *Org, err error) {
+	org = &Org{}
+	err = c.Do("POST", "/organization", nil, data, org)
+	return
+}
+
+func (c *Client) UpdateOrg(orgId string, data *payload.OrgPut) (
+	org *Org, err error) {
+
+	org = &Org{}
+	err = c.Do("PUT", apiPath("organization", orgId), nil, data, org)
+	return
+}
+
+func (c *Client) DeleteOrg(orgId string) (err error) {
+	err = c.Do("DELETE", apiPath("organization", orgId), nil, nil, nil)
+	return
+}
@@ -0,0 +1,180 @@
+package client
+
+import (
+	"encoding/json"
+
+	"github.com/pritunl/pritunl-web/payload"
+)
+
+type Server struct {
+	payload.Server
+	Id     string `json:"id"`
+	Status string `json:"status"`
+}
+
+type ServerOrg struct {
+	Id     string `json:"id"`
+	Server string `json:"server"`
+	Name   string `json:"name"`
+}
+
+type ServerHost struct {
+	Id      string `json:"id"`
+	Server  string `json:"server"`
+	Status  string `json:"status"`
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+type ServerLink struct {
+	Id              string `json:"id"`
+	Server          string `json:"server"`
+	Name            string `json:"name"`
+	UseLocalAddress bool   `json:"use_local_address"`
+}
+
+type Route struct {
+	payload.ServerRoute
+	Id     string `json:"id"`
+	Server string `json:"server"`
+}
+
+func (c *Client) Servers() (servers []*Server, err error) {
+	err = c.paginate("/server", "servers", nil,
+		func(data json.RawMessage) error {
+			server := &Server{}
+			servers = append(servers, server)
+			return json.Unmarshal(data, server)
+		})
+	return
+}
+
+func (c *Client) Server(serverId string) (server *Server, err error) {
+	server = &Server{}
+	err = c.Do("GET", apiPath("server", serverId), nil, nil, server)
+	return
+}
+
+func (c *Client) CreateServer(data *payload.Server) (
+	server *Server, err error) {
+
+	server = &Server{}
+	err = c.Do("POST", "/server", nil, data, server)
+	return
+}
+
+func (c *Client) UpdateServer(serverId string, data *payload.Server) (
+	server *Server, err error) {
+
+	server = &Server{}
+	err = c.Do("PUT", apiPath("server", serverId), nil, data, server)
+	return
+}
+
+func (c *Client) DeleteServer(serverId string) (err error) {
+	err = c.Do("DELETE", apiPath("server", serverId), nil, nil, nil)
+	return
+}
+
+func (c *Client) ServerOperation(serverId, operation string) (
+	server *Server, err error) {
+
+	server = &Server{}
+	err = c.Do("PUT", apiPath("server", serverId, "operation", operation),
+		nil, nil, server)
+	return
+}
+
+func (c *Client) ServerOrgs(serverId string) (orgs []*ServerOrg, err error) {
+	err = c.Do("GET", apiPath("server", serverId, "organization"),
+		nil, nil, &orgs)
+	return
+}
+
+func (c *Client) AttachServerOrg(serverId, orgId string) (err error) {
+	err = c.Do("PUT", apiPath("server", serverId, "organization", orgId),
+		nil, nil, nil)
+	return
+}
+
+func (c *Client) DetachServerOrg(serverId, orgId string) (err error) {
+	err = c.Do("DELETE", apiPath("server", serverId, "organization", orgId),
+		nil, nil, nil)
+	return
+}
+
+func (c *Client) ServerHosts(serverId string) (
+	hosts []*ServerHost, err error) {
+
+	err = c.Do("GET", apiPath("server", serverId, "host"), nil, nil, &hosts)
+	return
+}
+
+func (c *Client) AttachServerHost(serverId, hostId string) (err error) {
+	err = c.Do("PUT", apiPath("server", serverId, "host", hostId),
+		nil, nil, nil)
+	return
+}
+
+func (c *Client) DetachServerHost(serverId, hostId string) (err error) {
+	err = c.Do("DELETE", apiPath("server", serverId, "host", hostId),
+		nil, nil, nil)
+	return
+}
+
+func (c *Client) ServerLinks(serverId string) (
+	links []*ServerLink, err error) {
+
+	err = c.Do("GET", apiPath("server", serverId, "link"), nil, nil, &links)
+	return
+}
+
+func (c *Client) AttachServerLink(serverId, linkId string,
+	data *payload.ServerLink) (err error) {
+
+	err = c.Do("PUT", apiPath("server", serverId, "link", linkId),
+		nil, data, nil)
+	return
+}
+
+func (c *Client) DetachServerLink(serverId, linkId string) (err error) {
+	err = c.Do("DELETE", apiPath("server", serverId, "link", linkId),
+		nil, nil, nil)
+	return
+}
+
+func (c *Client) Routes(serverId string) (routes []*Route, err error) {
+	err = c.Do("GET", apiPath("server", serverId, "route"), nil, nil, &routes)
+	return
+}
+
+func (c *Client) CreateRoute(serverId string, data *payload.ServerRoute) (
+	route *Route, err error) {
+
+	route = &Route{}
+	err = c.Do("POST", apiPath("server", serverId, "route"), nil, data, route)
+	return
+}
+
+func (c *Client) CreateRoutes(serverId string,
+	data []*payload.ServerRoute) (routes []*Route, err error) {
+
+	err = c.Do("POST", apiPath("server", serverId, "routes"),
+		nil, data, &routes)
+	return
+}
+
+func (c *Client) UpdateRoute(serverId, routeId string,
+	data *payload.ServerRoute) (route *Route, err error) {
+
+	route = &Route{}
+	err = c.Do("PUT", apiPath("server", serverId, "route", routeId),
+		nil, data, route)
+	return
+}
+
+func (c *Client) DeleteRoute(serverId, routeId string) (err error) {
+	err = c.Do("DELETE", apiPath("server", serverId, "route", routeId),
+		nil, nil, nil)
+	return
+}
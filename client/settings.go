@@ -0,0 +1,23 @@
+package client
+
+import (
+	"github.com/pritunl/pritunl-web/payload"
+)
+
+type Settings struct {
+	payload.Settings
+}
+
+func (c *Client) Settings() (settings *Settings, err error) {
+	settings = &Settings{}
+	err = c.Do("GET", "/settings", nil, nil, settings)
+	return
+}
+
+func (c *Client) UpdateSettings(data *payload.Settings) (
+	settings *Settings, err error) {
+
+	settings = &Settings{}
+	err = c.Do("PUT", "/settings", nil, data, settings)
+	return
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"encoding/json"
+	"net/url"
+
+	"github.com/pritunl/pritunl-web/payload"
+)
+
+type User struct {
+	payload.UserPut
+	Id           string `json:"id"`
+	Organization string `json:"organization"`
+}
+
+func (c *Client) Users(orgId, search string) (users []*User, err error) {
+	query := url.Values{}
+	if search != "" {
+		query.Set("search", search)
+	}
+
+	err = c.paginate(apiPath("user", orgId), "users", query,
+		func(data json.RawMessage) error {
+			user := &User{}
+			users = append(users, user)
+			return json.Unmarshal(data, user)
+		})
+	return
+}
+
+func (c *Client) User(orgId, userId string) (user *User, err error) {
+	user = &User{}
+	err = c.Do("GET", apiPath("user", orgId, userId), nil, nil, user)
+	return
+}
+
+func (c *Client) CreateUser(orgId string, data *payload.UserPost) (
+	user *User, err error) {
+
+	user = &User{}
+	err = c.Do("POST", apiPath("user", orgId), nil, data, user)
+	return
+}
+
+func (c *Client) CreateUsers(orgId string, data []*payload.UserPost) (
+	users []*User, err error) {
+
+	err = c.Do("POST", apiPath("user", orgId, "multi"), nil, data, &users)
+	return
+}
+
+func (c *Client) UpdateUser(orgId, userId string, data *payload.UserPut) (
+	user *User, err error) {
+
+	user = &User{}
+	err = c.Do("PUT", apiPath("user", orgId, userId), nil, data, user)
+	return
+}
+
+func (c *Client) DeleteUser(orgId, userId string) (err error) {
+	err = c.Do("DELETE", apiPath("user", orgId, userId), nil, nil, nil)
+	return
+}
+
+func (c *Client) ResetUserOtp(orgId, userId string) (
+	user *User, err error) {
+
+	user = &User{}
+	err = c.Do("PUT", apiPath("user", orgId, userId, "otp_secret"),
+		nil, nil, user)
+	return
+}
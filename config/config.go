@@ -0,0 +1,127 @@
+package config
+
+type CertConfig struct {
+	Cert     string `json:"cert"`
+	Key      string `json:"key"`
+	CertFile string `json:"cert_file"`
+	KeyFile  string `json:"key_file"`
+}
+
+type PolicyConfig struct {
+	Allow []string `json:"allow"`
+	Deny  []string `json:"deny"`
+}
+
+type Config struct {
+	ReverseProxyHeader      string                       `json:"reverse_proxy_header" env:"REVERSE_PROXY_HEADER"`
+	ReverseProxyProtoHeader string                       `json:"reverse_proxy_proto_header" env:"REVERSE_PROXY_PROTO_HEADER"`
+	TrustedProxies          []string                     `json:"trusted_proxies" env:"TRUSTED_PROXIES"`
+	ProxyProtocolTrusted    []string                     `json:"proxy_protocol_trusted" env:"PROXY_PROTOCOL_TRUSTED"`
+	RedirectServer          bool                         `json:"redirect_server" env:"REDIRECT_SERVER"`
+	BindHost                string                       `json:"bind_host" env:"BIND_HOST"`
+	BindPort                int                          `json:"bind_port" env:"BIND_PORT"`
+	MetricsAddress          string                       `json:"metrics_address" env:"METRICS_ADDRESS"`
+	InternalAddress         string                       `json:"internal_address" env:"INTERNAL_ADDRESS"`
+	InternalServerName      string                       `json:"internal_server_name" env:"INTERNAL_SERVER_NAME"`
+	InternalCaPath          string                       `json:"internal_ca_path" env:"INTERNAL_CA_PATH"`
+	InternalCertPath        string                       `json:"internal_cert_path" env:"INTERNAL_CERT_PATH"`
+	InternalKeyPath         string                       `json:"internal_key_path" env:"INTERNAL_KEY_PATH"`
+	SslCert                 string                       `json:"ssl_cert" env:"SSL_CERT"`
+	SslCertFile             string                       `json:"ssl_cert_file"`
+	SslKey                  string                       `json:"ssl_key" env:"SSL_KEY"`
+	SslKeyFile              string                       `json:"ssl_key_file" env:"SSL_KEY_FILE"`
+	SslCerts                []CertConfig                 `json:"ssl_certs"`
+	SslCertDir              string                       `json:"ssl_cert_dir" env:"SSL_CERTS_DIR"`
+	SslDefaultName          string                       `json:"ssl_default_name" env:"SSL_DEFAULT_NAME"`
+	SslOcspStapling         bool                         `json:"ssl_ocsp_stapling" env:"SSL_OCSP_STAPLING"`
+	SslOcspResponder        string                       `json:"ssl_ocsp_responder" env:"SSL_OCSP_RESPONDER"`
+	TlsProfile              string                       `json:"tls_profile" env:"TLS_PROFILE"`
+	TlsMinVersion           string                       `json:"tls_min_version" env:"TLS_MIN_VERSION"`
+	TlsMaxVersion           string                       `json:"tls_max_version" env:"TLS_MAX_VERSION"`
+	TlsCipherSuites         []string                     `json:"tls_cipher_suites" env:"TLS_CIPHER_SUITES"`
+	TlsCurves               []string                     `json:"tls_curves" env:"TLS_CURVES"`
+	TlsAlpn                 []string                     `json:"tls_alpn" env:"TLS_ALPN"`
+	HstsMaxAge              int                          `json:"hsts_max_age" env:"HSTS_MAX_AGE"`
+	HstsIncludeSubdomains   bool                         `json:"hsts_include_subdomains" env:"HSTS_INCLUDE_SUBDOMAINS"`
+	HstsPreload             bool                         `json:"hsts_preload" env:"HSTS_PRELOAD"`
+	ContentSecurityPolicy   string                       `json:"content_security_policy" env:"CONTENT_SECURITY_POLICY"`
+	FrameOptions            string                       `json:"frame_options" env:"FRAME_OPTIONS"`
+	ReferrerPolicy          string                       `json:"referrer_policy" env:"REFERRER_POLICY"`
+	PermissionsPolicy       string                       `json:"permissions_policy" env:"PERMISSIONS_POLICY"`
+	SecurityHeadersOverride map[string]map[string]string `json:"security_headers_override" env:"SECURITY_HEADERS_OVERRIDE"`
+	CspReport               bool                         `json:"csp_report" env:"CSP_REPORT"`
+	CapturePath             string                       `json:"capture_path" env:"CAPTURE_PATH"`
+	ResponseHeaderAllow     []string                     `json:"response_header_allow" env:"RESPONSE_HEADER_ALLOW"`
+	ResponseHeaderDeny      []string                     `json:"response_header_deny" env:"RESPONSE_HEADER_DENY"`
+	CookieHttpOnly          []string                     `json:"cookie_httponly" env:"COOKIE_HTTPONLY"`
+	CookieSameSite          []string                     `json:"cookie_samesite" env:"COOKIE_SAMESITE"`
+	CookieHostPrefix        bool                         `json:"cookie_host_prefix" env:"COOKIE_HOST_PREFIX"`
+	AcmeDomains             []string                     `json:"acme_domains" env:"ACME_DOMAINS"`
+	AcmeEmail               string                       `json:"acme_email" env:"ACME_EMAIL"`
+	AcmeDirectory           string                       `json:"acme_directory" env:"ACME_DIRECTORY"`
+	AcmeCaPath              string                       `json:"acme_ca_path" env:"ACME_CA_PATH"`
+	AcmeCachePath           string                       `json:"acme_cache_path" env:"ACME_CACHE_PATH"`
+	AdminClientCert         string                       `json:"admin_client_cert" env:"ADMIN_CLIENT_CERT"`
+	AdminClientCaPath       string                       `json:"admin_client_ca_path" env:"ADMIN_CLIENT_CA_PATH"`
+	AdminClientCertHosts    []string                     `json:"admin_client_cert_hosts" env:"ADMIN_CLIENT_CERT_HOSTS"`
+	WebSecret               string                       `json:"web_secret" env:"WEB_SECRET"`
+	WebSecretFile           string                       `json:"web_secret_file" env:"WEB_SECRET_FILE"`
+	WebStrict               bool                         `json:"web_strict" env:"WEB_STRICT"`
+	WebCsrf                 string                       `json:"web_csrf" env:"WEB_CSRF"`
+	WebSessionRenew         float64                      `json:"web_session_renew" env:"WEB_SESSION_RENEW"`
+	WebSessionTtl           int                          `json:"web_session_ttl" env:"WEB_SESSION_TTL"`
+	WebSessionMaxAge        int                          `json:"web_session_max_age" env:"WEB_SESSION_MAX_AGE"`
+	WebTokenBinding         string                       `json:"web_token_binding" env:"WEB_TOKEN_BINDING"`
+	PublicHosts             []string                     `json:"public_hosts" env:"PUBLIC_HOSTS"`
+	ApiAuthWindow           int                          `json:"api_auth_window" env:"API_AUTH_WINDOW"`
+	ApiNonceCacheSize       int                          `json:"api_nonce_cache_size" env:"API_NONCE_CACHE_SIZE"`
+	ApiSecretsPath          string                       `json:"api_secrets_path" env:"API_SECRETS_PATH"`
+	RunAsUser               string                       `json:"run_as_user" env:"RUN_AS_USER"`
+	RunAsGroup              string                       `json:"run_as_group" env:"RUN_AS_GROUP"`
+	RunNoNewPrivs           bool                         `json:"run_no_new_privs" env:"RUN_NO_NEW_PRIVS"`
+	RunLandlock             bool                         `json:"run_landlock" env:"RUN_LANDLOCK"`
+	Policies                map[string]PolicyConfig      `json:"policies"`
+}
+
+func Default() *Config {
+	return &Config{
+		HstsMaxAge:     31536000,
+		FrameOptions:   "DENY",
+		ReferrerPolicy: "strict-origin-when-cross-origin",
+		PermissionsPolicy: "camera=(), microphone=(), " +
+			"geolocation=(), payment=()",
+		ResponseHeaderDeny: []string{"Server", "X-Powered-By"},
+		CookieHttpOnly:     []string{"token"},
+		CookieSameSite:     []string{"token=strict", "*=lax"},
+		AcmeCachePath:      "/var/lib/pritunl-web/acme",
+		WebStrict:          true,
+		WebSessionTtl:      3600,
+		WebSessionMaxAge:   86400,
+		ApiAuthWindow:      300,
+		ApiNonceCacheSize:  100000,
+		Policies:           map[string]PolicyConfig{},
+	}
+}
+
+func Load(path string) (conf *Config, err error) {
+	conf = Default()
+
+	if path != "" {
+		err = conf.loadFile(path)
+		if err != nil {
+			return
+		}
+	}
+
+	err = conf.loadEnv()
+	if err != nil {
+		return
+	}
+
+	err = conf.resolveSecrets(false)
+	if err != nil {
+		return
+	}
+
+	return
+}
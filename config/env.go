@@ -0,0 +1,186 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-web/errortypes"
+)
+
+func splitList(val string) (items []string) {
+	for _, item := range strings.Split(val, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return
+}
+
+func getenv(key string) string {
+	val := os.Getenv(key)
+	os.Unsetenv(key)
+	return val
+}
+
+func setField(field reflect.Value, key, val string) (err error) {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(val)
+	case reflect.Bool:
+		switch key {
+		case "WEB_STRICT":
+			field.SetBool(val != "false")
+		case "REDIRECT_SERVER":
+			field.SetBool(val == "true")
+		default:
+			b, e := strconv.ParseBool(val)
+			if e != nil {
+				err = e
+				break
+			}
+			field.SetBool(b)
+		}
+	case reflect.Int:
+		n, e := strconv.Atoi(val)
+		if e != nil {
+			err = e
+			break
+		}
+		field.SetInt(int64(n))
+	case reflect.Float64:
+		f, e := strconv.ParseFloat(val, 64)
+		if e != nil {
+			err = e
+			break
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		field.Set(reflect.ValueOf(splitList(val)))
+	case reflect.Map:
+		ptr := reflect.New(field.Type())
+		err = json.Unmarshal([]byte(val), ptr.Interface())
+		if err != nil {
+			break
+		}
+		field.Set(ptr.Elem())
+	}
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrapf(err, "config: Invalid value for '%s'", key),
+		}
+		return
+	}
+
+	return
+}
+
+func overrideSecret(set map[string]bool, key, credName string,
+	fileEnv bool, val, path *string) {
+
+	if set[key] {
+		*path = ""
+	} else if fileEnv && set[key+"_FILE"] {
+		*val = ""
+	} else if credPath := credentialPath(credName); credPath != "" {
+		*val = ""
+		*path = credPath
+	}
+}
+
+func (c *Config) loadEnv() (err error) {
+	val := reflect.ValueOf(c).Elem()
+	typ := val.Type()
+	set := map[string]bool{}
+
+	for i := 0; i < typ.NumField(); i++ {
+		key := typ.Field(i).Tag.Get("env")
+		if key == "" {
+			continue
+		}
+
+		envVal := getenv(key)
+		if envVal == "" {
+			continue
+		}
+		set[key] = true
+
+		err = setField(val.Field(i), key, envVal)
+		if err != nil {
+			return
+		}
+	}
+
+	overrideSecret(set, "SSL_CERT", "ssl_cert", false,
+		&c.SslCert, &c.SslCertFile)
+	overrideSecret(set, "SSL_KEY", "ssl_key", true,
+		&c.SslKey, &c.SslKeyFile)
+	overrideSecret(set, "WEB_SECRET", "web_secret", true,
+		&c.WebSecret, &c.WebSecretFile)
+
+	for i := 1; ; i++ {
+		certKey := "SSL_CERT_" + strconv.Itoa(i)
+		keyKey := "SSL_KEY_" + strconv.Itoa(i)
+
+		cert := CertConfig{
+			Cert:    getenv(certKey),
+			Key:     getenv(keyKey),
+			KeyFile: getenv(keyKey + "_FILE"),
+		}
+		if cert.Key != "" {
+			cert.KeyFile = ""
+		} else if cert.KeyFile == "" {
+			cert.KeyFile = credentialPath(strings.ToLower(keyKey))
+		}
+		if cert.Cert == "" || (cert.Key == "" && cert.KeyFile == "") {
+			break
+		}
+
+		if i == 1 {
+			c.SslCerts = nil
+		}
+		c.SslCerts = append(c.SslCerts, cert)
+	}
+
+	for _, item := range os.Environ() {
+		key := strings.SplitN(item, "=", 2)[0]
+		if !strings.HasPrefix(key, "POLICY_") {
+			continue
+		}
+
+		var class string
+		var allow bool
+		if strings.HasSuffix(key, "_ALLOW") {
+			class = strings.TrimSuffix(key, "_ALLOW")
+			allow = true
+		} else if strings.HasSuffix(key, "_DENY") {
+			class = strings.TrimSuffix(key, "_DENY")
+		} else {
+			continue
+		}
+		class = strings.ToLower(strings.TrimPrefix(class, "POLICY_"))
+
+		envVal := getenv(key)
+		if envVal == "" {
+			continue
+		}
+
+		if c.Policies == nil {
+			c.Policies = map[string]PolicyConfig{}
+		}
+
+		pol := c.Policies[class]
+		if allow {
+			pol.Allow = splitList(envVal)
+		} else {
+			pol.Deny = splitList(envVal)
+		}
+		c.Policies[class] = pol
+	}
+
+	return
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLegacyBoolEnv(t *testing.T) {
+	tests := []struct {
+		key      string
+		val      string
+		expected bool
+	}{
+		{"WEB_STRICT", "false", false},
+		{"WEB_STRICT", "0", true},
+		{"WEB_STRICT", "F", true},
+		{"WEB_STRICT", "yes", true},
+		{"REDIRECT_SERVER", "true", true},
+		{"REDIRECT_SERVER", "1", false},
+		{"REDIRECT_SERVER", "yes", false},
+	}
+
+	for _, test := range tests {
+		t.Setenv(test.key, test.val)
+
+		conf := Default()
+		err := conf.loadEnv()
+		if err != nil {
+			t.Fatalf("%s=%s failed: %s", test.key, test.val, err)
+		}
+
+		val := conf.WebStrict
+		if test.key == "REDIRECT_SERVER" {
+			val = conf.RedirectServer
+		}
+		if val != test.expected {
+			t.Errorf("%s=%s parsed as %t, expected %t",
+				test.key, test.val, val, test.expected)
+		}
+	}
+}
+
+func TestSslCertCredential(t *testing.T) {
+	credDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(credDir, "ssl_cert"),
+		[]byte("cert"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("CREDENTIALS_DIRECTORY", credDir)
+	t.Setenv("SSL_CERT_FILE", "/etc/ssl/certs/ca-certificates.crt")
+
+	conf := Default()
+	conf.SslCert = "inline"
+
+	err = conf.loadEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if conf.SslCert != "" ||
+		conf.SslCertFile != filepath.Join(credDir, "ssl_cert") {
+
+		t.Fatalf("ssl cert '%s' file '%s', expected credential",
+			conf.SslCert, conf.SslCertFile)
+	}
+}
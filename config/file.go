@@ -0,0 +1,79 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pritunl/pritunl-web/errortypes"
+	"gopkg.in/yaml.v3"
+)
+
+func decodeFile(path string, data []byte) (raw map[string]interface{},
+	err error) {
+
+	raw = map[string]interface{}{}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".json":
+		err = json.Unmarshal(data, &raw)
+	default:
+		err = errors.Newf("config: Unknown config file format '%s'",
+			filepath.Ext(path))
+	}
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrapf(err, "config: Failed to parse config file '%s'",
+				path),
+		}
+		return
+	}
+
+	return
+}
+
+func (c *Config) loadFile(path string) (err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrapf(err, "config: Failed to read config file '%s'",
+				path),
+		}
+		return
+	}
+
+	raw, err := decodeFile(path, data)
+	if err != nil {
+		return
+	}
+
+	rawByt, err := json.Marshal(raw)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrapf(err, "config: Failed to parse config file '%s'",
+				path),
+		}
+		return
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(rawByt))
+	decoder.DisallowUnknownFields()
+
+	err = decoder.Decode(c)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrapf(err, "config: Invalid config file '%s'", path),
+		}
+		return
+	}
+
+	return
+}
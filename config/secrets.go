@@ -0,0 +1,104 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-web/errortypes"
+)
+
+func credentialPath(name string) string {
+	credDir := os.Getenv("CREDENTIALS_DIRECTORY")
+	if credDir == "" {
+		return ""
+	}
+
+	credPath := filepath.Join(credDir, name)
+	_, err := os.Stat(credPath)
+	if err != nil {
+		return ""
+	}
+
+	return credPath
+}
+
+func readSecretFile(path string, pem bool) (val string, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrapf(err, "config: Failed to read secret file '%s'",
+				path),
+		}
+		return
+	}
+
+	if pem && bytes.HasPrefix(data, []byte("-----BEGIN")) {
+		val = base64.StdEncoding.EncodeToString(data)
+		return
+	}
+
+	val = string(bytes.TrimRight(data, "\r\n"))
+	return
+}
+
+func resolveSecret(val, path *string, pem, reload bool) (err error) {
+	if *path == "" {
+		if pem && strings.HasPrefix(*val, "-----BEGIN") {
+			*val = base64.StdEncoding.EncodeToString([]byte(*val))
+		}
+		return
+	}
+
+	if *val != "" && !reload {
+		*path = ""
+		return resolveSecret(val, path, pem, reload)
+	}
+
+	*val, err = readSecretFile(*path, pem)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (c *Config) resolveSecrets(reload bool) (err error) {
+	err = resolveSecret(&c.SslCert, &c.SslCertFile, true, reload)
+	if err != nil {
+		return
+	}
+
+	err = resolveSecret(&c.SslKey, &c.SslKeyFile, true, reload)
+	if err != nil {
+		return
+	}
+
+	for i := range c.SslCerts {
+		cert := &c.SslCerts[i]
+
+		err = resolveSecret(&cert.Cert, &cert.CertFile, true, reload)
+		if err != nil {
+			return
+		}
+
+		err = resolveSecret(&cert.Key, &cert.KeyFile, true, reload)
+		if err != nil {
+			return
+		}
+	}
+
+	err = resolveSecret(&c.WebSecret, &c.WebSecretFile, false, reload)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (c *Config) ReloadSecrets() (err error) {
+	return c.resolveSecrets(true)
+}
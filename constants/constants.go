@@ -1,16 +1,120 @@
 package constants
 
+import (
+	"net"
+	"sync/atomic"
+)
+
+type ProxyConfig struct {
+	Trusted         []*net.IPNet
+	ProtocolTrusted []*net.IPNet
+}
+
+type InternalConfig struct {
+	ServerName string
+	CaPath     string
+	CertPath   string
+	KeyPath    string
+}
+
+type CertsConfig struct {
+	ExtraCerts    []string
+	ExtraKeys     []string
+	Dir           string
+	DefaultName   string
+	OcspStapling  bool
+	OcspResponder string
+}
+
+type TlsConfig struct {
+	Profile      string
+	MinVersion   string
+	MaxVersion   string
+	CipherSuites string
+	Curves       string
+	Alpn         string
+}
+
+type HeadersConfig struct {
+	HstsMaxAge            int
+	HstsIncludeSubdomains bool
+	HstsPreload           bool
+	ContentSecurityPolicy string
+	FrameOptions          string
+	ReferrerPolicy        string
+	PermissionsPolicy     string
+	Override              map[string]map[string]string
+	CspReport             bool
+	ResponseAllow         map[string]bool
+	ResponseDeny          map[string]bool
+}
+
+type CookiesConfig struct {
+	HttpOnly   []string
+	SameSite   map[string]string
+	HostPrefix bool
+}
+
+type AcmeConfig struct {
+	Domains   []string
+	Email     string
+	Directory string
+	CaPath    string
+	CachePath string
+}
+
+type AdminClientConfig struct {
+	Mode   string
+	CaPath string
+	Hosts  []string
+}
+
+type SessionConfig struct {
+	Csrf         string
+	Renew        float64
+	Ttl          int
+	MaxAge       int
+	TokenBinding string
+}
+
+type ApiConfig struct {
+	AuthWindow     int
+	NonceCacheSize int
+	SecretsPath    string
+}
+
+type RunConfig struct {
+	User       string
+	Group      string
+	NoNewPrivs bool
+	Landlock   bool
+}
+
 var (
 	ReverseProxyHeader      string
 	ReverseProxyProtoHeader string
-	RedirectServer          string
+	RedirectServer          bool
 	BindHost                string
 	BindPort                string
+	MetricsAddress          string
 	InternalHost            string
 	SslCert                 string
 	SslKey                  string
-	WebSecret               *[32]byte
+	WebSecret               atomic.Pointer[[32]byte]
 	WebStrict               bool
+	CapturePath             string
+	PublicHosts             []string
 	Ssl                     bool
 	Scheme                  string
+	Proxy                   ProxyConfig
+	Internal                InternalConfig
+	Certs                   CertsConfig
+	Tls                     TlsConfig
+	Headers                 HeadersConfig
+	Cookies                 CookiesConfig
+	Acme                    AcmeConfig
+	AdminClient             AdminClientConfig
+	Session                 SessionConfig
+	Api                     ApiConfig
+	Run                     RunConfig
 )
@@ -11,3 +11,15 @@ type ParseError struct {
 type RequestError struct {
 	errors.DropboxError
 }
+
+type ReadError struct {
+	errors.DropboxError
+}
+
+type UnknownError struct {
+	errors.DropboxError
+}
+
+type WriteError struct {
+	errors.DropboxError
+}
@@ -0,0 +1,77 @@
+package fakebackend
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+type Request struct {
+	Method      string
+	Path        string
+	EscapedPath string
+	Query       url.Values
+	RawQuery    string
+	Header      http.Header
+	Body        []byte
+}
+
+type Backend struct {
+	Header   http.Header
+	Body     []byte
+	Respond  func(w http.ResponseWriter, req *Request, index int)
+	lock     sync.Mutex
+	requests []*Request
+}
+
+func New() *Backend {
+	return &Backend{
+		Header: http.Header{},
+		Body:   []byte("{}"),
+	}
+}
+
+func (b *Backend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	req := &Request{
+		Method:      r.Method,
+		Path:        r.URL.Path,
+		EscapedPath: r.URL.EscapedPath(),
+		Query:       r.URL.Query(),
+		RawQuery:    r.URL.RawQuery,
+		Header:      r.Header.Clone(),
+		Body:        body,
+	}
+
+	b.lock.Lock()
+	index := len(b.requests)
+	b.requests = append(b.requests, req)
+	b.lock.Unlock()
+
+	if b.Respond != nil {
+		b.Respond(w, req, index)
+		return
+	}
+
+	for key, vals := range b.Header {
+		for _, val := range vals {
+			w.Header().Add(key, val)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b.Body)
+}
+
+func (b *Backend) Requests() []*Request {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return append([]*Request{}, b.requests...)
+}
+
+func (b *Backend) Reset() {
+	b.lock.Lock()
+	b.requests = nil
+	b.lock.Unlock()
+}
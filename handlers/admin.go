@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-web/payload"
 	"github.com/pritunl/pritunl-web/request"
 	"github.com/pritunl/pritunl-web/utils"
 )
@@ -20,18 +21,7 @@ func adminGet(c *gin.Context) {
 	req.Do(c)
 }
 
-type adminPutData struct {
-	Username  string `json:"username"`
-	Password  string `json:"password"`
-	YubikeyId string `json:"yubikey_id"`
-	SuperUser bool   `json:"super_user"`
-	AuthApi   bool   `json:"auth_api"`
-	Token     string `json:"token"`
-	Secret    string `json:"secret"`
-	Disabled  bool   `json:"disabled"`
-	OtpAuth   bool   `json:"otp_auth"`
-	OtpSecret bool   `json:"otp_secret"`
-}
+type adminPutData = payload.AdminPut
 
 func adminPut(c *gin.Context) {
 	adminId := utils.FilterStr(c.Params.ByName("admin_id"), 128)
@@ -46,15 +36,7 @@ func adminPut(c *gin.Context) {
 	req.Do(c)
 }
 
-type adminPostData struct {
-	Username  string `json:"username"`
-	Password  string `json:"password"`
-	YubikeyId string `json:"yubikey_id"`
-	OtpAuth   bool   `json:"otp_auth"`
-	AuthApi   bool   `json:"auth_api"`
-	Disabled  bool   `json:"disabled"`
-	SuperUser bool   `json:"super_user"`
-}
+type adminPostData = payload.AdminPost
 
 func adminPost(c *gin.Context) {
 	data := &adminPostData{}
@@ -21,7 +21,19 @@ func authSessionPost(c *gin.Context) {
 		Json:   data,
 	}
 
-	req.Do(c)
+	resp, err := req.Send(c)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	err = sessionResponse(c, resp)
+	if err != nil {
+		c.AbortWithError(500, err)
+		return
+	}
+
+	request.Write(c, resp)
 }
 
 func authSessionDelete(c *gin.Context) {
@@ -39,5 +51,17 @@ func authStateGet(c *gin.Context) {
 		Path:   "/state",
 	}
 
-	req.Do(c)
+	resp, err := req.Send(c)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	err = csrfResponse(c, resp)
+	if err != nil {
+		c.AbortWithError(500, err)
+		return
+	}
+
+	request.Write(c, resp)
 }
@@ -0,0 +1,113 @@
+package handlers_test
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-web/constants"
+	"github.com/pritunl/pritunl-web/fakebackend"
+	"github.com/pritunl/pritunl-web/handlers"
+	"github.com/pritunl/pritunl-web/request"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+func newFakeBackend(t testing.TB) (backend *fakebackend.Backend) {
+	backend = fakebackend.New()
+
+	server := httptest.NewServer(backend)
+	t.Cleanup(server.Close)
+	constants.InternalHost = server.Listener.Addr().String()
+
+	return
+}
+
+type harness struct {
+	backend *fakebackend.Backend
+	router  *gin.Engine
+	token   string
+}
+
+func newHarness(t testing.TB) (h *harness) {
+	gin.SetMode(gin.TestMode)
+
+	backend := newFakeBackend(t)
+
+	secret := &[32]byte{}
+	rand.Read(secret[:])
+	constants.WebSecret.Store(secret)
+	constants.Scheme = "http"
+	constants.WebStrict = true
+	constants.Api.AuthWindow = 300
+	constants.Api.NonceCacheSize = 1000
+	constants.Session.Ttl = 3600
+	constants.Session.MaxAge = 86400
+	constants.Session.Renew = 0
+
+	err := request.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	handlers.Register(router)
+
+	h = &harness{
+		backend: backend,
+		router:  router,
+		token:   sealToken(t, secret),
+	}
+
+	return
+}
+
+func sealToken(t testing.TB, secret *[32]byte) string {
+	data, err := json.Marshal(&handlers.Token{
+		Id:  "test-session",
+		Ttl: time.Now().Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var nonce [24]byte
+	rand.Read(nonce[:])
+
+	sealed := secretbox.Seal(nonce[:], data, &nonce, secret)
+	return base64.URLEncoding.EncodeToString(sealed)
+}
+
+func (h *harness) Do(method, target, data string, authed bool) (
+	resp *httptest.ResponseRecorder) {
+
+	h.backend.Reset()
+
+	var body io.Reader
+	if data != "" {
+		body = strings.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, target, body)
+	req.RemoteAddr = "198.51.100.7:43210"
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if authed {
+		req.AddCookie(&http.Cookie{
+			Name:  "token",
+			Value: h.token,
+		})
+	}
+
+	resp = httptest.NewRecorder()
+	h.router.ServeHTTP(resp, req)
+
+	return
+}
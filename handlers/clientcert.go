@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-web/constants"
+	"github.com/pritunl/pritunl-web/request"
+)
+
+func clientCertValid(c *gin.Context) bool {
+	if constants.AdminClient.Mode != "required" {
+		return true
+	}
+
+	return request.ClientCert(c.Request) != nil
+}
+
+func RequireClientCert(c *gin.Context) {
+	if !clientCertValid(c) {
+		request.AbortWithStatus(c, 401, "Client certificate required")
+		return
+	}
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dropbox/godropbox/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-web/constants"
+	"github.com/pritunl/pritunl-web/errortypes"
+	"github.com/pritunl/pritunl-web/request"
+	"github.com/pritunl/pritunl-web/utils"
+	"github.com/sirupsen/logrus"
+)
+
+func csrfToken(token *Token) string {
+	hash := hmac.New(sha256.New, constants.WebSecret.Load()[:])
+	hash.Write([]byte("csrf&" + token.Id))
+	return base64.RawURLEncoding.EncodeToString(hash.Sum(nil))
+}
+
+func csrfSafeMethod(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS":
+		return true
+	}
+	return false
+}
+
+func csrfHostAllowed(c *gin.Context, host string) bool {
+	host = strings.ToLower(utils.StripPort(host))
+	if host == "" {
+		return false
+	}
+
+	if len(constants.PublicHosts) == 0 {
+		return host == strings.ToLower(utils.StripPort(c.Request.Host))
+	}
+
+	for _, publicHost := range constants.PublicHosts {
+		if host == strings.ToLower(publicHost) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func csrfOriginValid(c *gin.Context) (reason string) {
+	fetchSite := c.Request.Header.Get("Sec-Fetch-Site")
+	if fetchSite != "" && fetchSite != "same-origin" && fetchSite != "none" {
+		return "Cross site request"
+	}
+
+	origin := c.Request.Header.Get("Origin")
+	if origin == "" || origin == "null" {
+		origin = c.Request.Header.Get("Referer")
+	}
+	if origin == "" {
+		if fetchSite != "" {
+			return ""
+		}
+		return "Missing origin"
+	}
+
+	originUrl, err := url.Parse(origin)
+	if err != nil || !csrfHostAllowed(c, originUrl.Host) {
+		return "Origin not allowed"
+	}
+
+	return ""
+}
+
+func csrfDeny(c *gin.Context, reason string) {
+	logrus.WithFields(logrus.Fields{
+		"client_ip": request.ClientIp(c),
+		"method":    c.Request.Method,
+		"path":      c.Request.URL.Path,
+		"reason":    reason,
+	}).Warn("handlers: CSRF validation failed")
+
+	request.AbortWithStatus(c, 403, "CSRF validation failed")
+}
+
+func Csrf(c *gin.Context) {
+	if constants.Session.Csrf == "" || !c.GetBool("validated") {
+		return
+	}
+
+	tokenInf, _ := c.Get("token")
+	token, _ := tokenInf.(*Token)
+
+	if csrfSafeMethod(c.Request.Method) {
+		return
+	}
+
+	if c.Request.Header.Get("Auth-Token") != "" &&
+		c.Request.Header.Get("Auth-Signature") != "" {
+
+		return
+	}
+
+	reason := csrfOriginValid(c)
+	if reason != "" {
+		csrfDeny(c, reason)
+		return
+	}
+
+	if constants.Session.Csrf == "token" {
+		if token == nil {
+			csrfDeny(c, "Missing session token")
+			return
+		}
+
+		csrfHeader := c.Request.Header.Get("Csrf-Token")
+		sep := strings.LastIndex(csrfHeader, ".")
+		if sep == -1 || !hmac.Equal(
+			[]byte(csrfHeader[sep+1:]),
+			[]byte(csrfToken(token)),
+		) {
+
+			csrfDeny(c, "Invalid token")
+			return
+		}
+
+		c.Request.Header.Set("Csrf-Token", csrfHeader[:sep])
+	}
+}
+
+func csrfResponse(c *gin.Context, resp *http.Response) (err error) {
+	tokenInf, _ := c.Get("token")
+	token, _ := tokenInf.(*Token)
+
+	if constants.Session.Csrf != "token" || token == nil ||
+		resp.StatusCode != 200 {
+
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10485760))
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "handlers: Failed to read response"),
+		}
+		return
+	}
+
+	data := map[string]json.RawMessage{}
+	backendToken := ""
+	if json.Unmarshal(body, &data) == nil &&
+		json.Unmarshal(data["csrf_token"], &backendToken) == nil &&
+		backendToken != "" {
+
+		data["csrf_token"], err = json.Marshal(
+			backendToken + "." + csrfToken(token))
+		if err == nil {
+			body, err = json.Marshal(data)
+		}
+		if err != nil {
+			err = &errortypes.ParseError{
+				errors.Wrap(err, "handlers: Failed to marshal response"),
+			}
+			return
+		}
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Del("Content-Length")
+
+	return
+}
@@ -0,0 +1,85 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pritunl/pritunl-web/constants"
+)
+
+func (h *harness) DoCsrf(method, target, csrf string) (
+	resp *httptest.ResponseRecorder) {
+
+	h.backend.Reset()
+
+	req := httptest.NewRequest(method, target, strings.NewReader("{}"))
+	req.RemoteAddr = clientIp + ":43210"
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", "https://example.com")
+	if csrf != "" {
+		req.Header.Set("Csrf-Token", csrf)
+	}
+	req.AddCookie(&http.Cookie{
+		Name:  "token",
+		Value: h.token,
+	})
+
+	resp = httptest.NewRecorder()
+	h.router.ServeHTTP(resp, req)
+
+	return
+}
+
+func TestCsrfToken(t *testing.T) {
+	constants.Session.Csrf = "token"
+	defer func() {
+		constants.Session.Csrf = ""
+	}()
+
+	h := newHarness(t)
+	h.backend.Body = []byte(`{"csrf_token": "backend", "super_user": true}`)
+
+	resp := h.Do("GET", "/state", "", true)
+	if resp.Code != 200 {
+		t.Fatalf("state status %d, expected 200", resp.Code)
+	}
+
+	state := map[string]interface{}{}
+	err := json.Unmarshal(resp.Body.Bytes(), &state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	csrf, _ := state["csrf_token"].(string)
+	if !strings.HasPrefix(csrf, "backend.") || state["super_user"] != true {
+		t.Fatalf("state response %s not rewritten", resp.Body.Bytes())
+	}
+
+	resp = h.DoCsrf("PUT", "/settings", csrf)
+	if resp.Code != 200 {
+		t.Fatalf("settings status %d, expected 200", resp.Code)
+	}
+
+	reqs := h.backend.Requests()
+	if len(reqs) != 1 || reqs[0].Header.Get("Csrf-Token") != "backend" {
+		t.Fatal("backend did not receive backend csrf token")
+	}
+
+	for _, csrf := range []string{
+		"",
+		"backend",
+		"backend.invalid",
+		strings.TrimSuffix(csrf, csrf[len(csrf)-1:]),
+	} {
+		resp = h.DoCsrf("PUT", "/settings", csrf)
+		if resp.Code != 403 {
+			t.Errorf("csrf %q status %d, expected 403", csrf, resp.Code)
+		}
+		if len(h.backend.Requests()) != 0 {
+			t.Errorf("csrf %q reached backend", csrf)
+		}
+	}
+}
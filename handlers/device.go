@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-web/payload"
 	"github.com/pritunl/pritunl-web/request"
 	"github.com/pritunl/pritunl-web/utils"
 )
@@ -15,10 +16,7 @@ func deviceUnregisteredGet(c *gin.Context) {
 	req.Do(c)
 }
 
-type deviceRegisterPutData struct {
-	Name   string `json:"name"`
-	RegKey string `json:"reg_key"`
-}
+type deviceRegisterPutData = payload.DeviceRegister
 
 func deviceRegisterPut(c *gin.Context) {
 	data := &deviceRegisterPutData{}
@@ -0,0 +1,147 @@
+package handlers_test
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/pritunl/pritunl-web/constants"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+func sealRaw(data []byte) string {
+	var nonce [24]byte
+	rand.Read(nonce[:])
+
+	sealed := secretbox.Seal(nonce[:], data, &nonce,
+		constants.WebSecret.Load())
+	return base64.URLEncoding.EncodeToString(sealed)
+}
+
+func (h *harness) DoCookie(target, cookie string) (
+	resp *httptest.ResponseRecorder) {
+
+	h.backend.Reset()
+
+	req := httptest.NewRequest("GET", target, nil)
+	req.RemoteAddr = "198.51.100.7:43210"
+	req.Header.Set("Cookie", "token="+cookie)
+
+	resp = httptest.NewRecorder()
+	h.router.ServeHTTP(resp, req)
+
+	return
+}
+
+func FuzzAuthorizeCookie(f *testing.F) {
+	h := newHarness(f)
+
+	f.Add(h.token)
+	f.Add("")
+	f.Add("AAAA")
+	f.Add(strings.Repeat("A", 40))
+	f.Add(base64.URLEncoding.EncodeToString(make([]byte, 28)))
+	f.Add("%zz")
+
+	f.Fuzz(func(t *testing.T, cookie string) {
+		resp := h.DoCookie("/settings", cookie)
+
+		for _, req := range h.backend.Requests() {
+			if req.Header.Get("PR-Validated") == "true" &&
+				cookie != h.token {
+
+				t.Fatalf("forged cookie %q validated", cookie)
+			}
+		}
+
+		if resp.Code != 200 && resp.Code != 401 {
+			t.Fatalf("unexpected status %d", resp.Code)
+		}
+	})
+}
+
+func FuzzAuthorizeToken(f *testing.F) {
+	h := newHarness(f)
+
+	f.Add([]byte(`{"id":"test-session","ttl":4102444800}`))
+	f.Add([]byte(`{"id":"test-session","ttl":1}`))
+	f.Add([]byte(`{"id":"","ttl":4102444800,"expires":1}`))
+	f.Add([]byte(`{"id":"a","ttl":4102444800,"network":"x",` +
+		`"agent":"y","cert":"z"}`))
+	f.Add([]byte(`null`))
+	f.Add([]byte(`[]`))
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		resp := h.DoCookie("/settings", sealRaw(data))
+
+		if resp.Code != 200 && resp.Code != 401 {
+			t.Fatalf("unexpected status %d", resp.Code)
+		}
+	})
+}
+
+func FuzzStaticPath(f *testing.F) {
+	h := newHarness(f)
+
+	f.Add("app/main.js")
+	f.Add("../../etc/passwd")
+	f.Add("..../..../etc/passwd")
+	f.Add(".../...//etc/passwd")
+	f.Add("%2e%2e/%2e%2e/etc/passwd")
+	f.Add("a/..%2f..%2fcheck")
+	f.Add("app/main.js%3Fx=1%23y")
+	f.Add("..\\..\\etc\\passwd")
+
+	f.Fuzz(func(t *testing.T, pth string) {
+		target := "/s/" + strings.TrimLeft(pth, "/")
+		u, err := url.ParseRequestURI(target)
+		if err != nil || u.RawQuery != "" || u.Fragment != "" ||
+			strings.ContainsAny(target, " \t\r\n") {
+
+			return
+		}
+
+		resp := h.DoCookie(target, h.token)
+		if resp.Code == http.StatusMovedPermanently ||
+			resp.Code == http.StatusTemporaryRedirect ||
+			resp.Code == http.StatusNotFound {
+
+			return
+		}
+
+		reqs := h.backend.Requests()
+		if len(reqs) != 1 {
+			t.Fatalf("expected 1 backend request, got %d (status %d)",
+				len(reqs), resp.Code)
+		}
+		backendPath := reqs[0].Path
+
+		if !strings.HasPrefix(backendPath, "/s/") &&
+			backendPath != "/s" {
+
+			t.Fatalf("backend path %q escapes static root", backendPath)
+		}
+
+		if backendPath != "/s/" && path.Clean(backendPath) != backendPath {
+			t.Fatalf("backend path %q is not clean", backendPath)
+		}
+
+		for _, elem := range strings.Split(backendPath, "/") {
+			if elem == ".." {
+				t.Fatalf("backend path %q contains traversal",
+					backendPath)
+			}
+		}
+
+		if reqs[0].Query.Encode() != "" {
+			t.Fatalf("backend query %q injected from path %q",
+				reqs[0].Query.Encode(), target)
+		}
+	})
+}
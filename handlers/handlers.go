@@ -1,8 +1,6 @@
 package handlers
 
 import (
-	"encoding/base64"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -13,15 +11,19 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/pritunl/pritunl-web/constants"
 	"github.com/pritunl/pritunl-web/errortypes"
+	"github.com/pritunl/pritunl-web/policy"
 	"github.com/pritunl/pritunl-web/request"
 	"github.com/pritunl/pritunl-web/utils"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/crypto/nacl/secretbox"
 )
 
 type Token struct {
-	Id  string `json:"id"`
-	Ttl int64  `json:"ttl"`
+	Id      string `json:"id"`
+	Ttl     int64  `json:"ttl"`
+	Expires int64  `json:"expires,omitempty"`
+	Network string `json:"network,omitempty"`
+	Agent   string `json:"agent,omitempty"`
+	Cert    string `json:"cert,omitempty"`
 }
 
 func Limiter(c *gin.Context) {
@@ -46,7 +48,8 @@ func Errors(c *gin.Context) {
 	c.Next()
 	for _, err := range c.Errors {
 		logrus.WithFields(logrus.Fields{
-			"error": err,
+			"client_ip": request.ClientIp(c),
+			"error":     err,
 		}).Error("handlers: Handler error")
 	}
 }
@@ -80,7 +83,14 @@ func Unauthorize(c *gin.Context) {
 }
 
 func Authorize(c *gin.Context) {
-	if constants.WebSecret == nil {
+	if !clientCertValid(c) {
+		c.Set("validated", false)
+		request.AbortWithStatus(c, 401, "Client certificate required")
+		return
+	}
+
+	webSecret := constants.WebSecret.Load()
+	if webSecret == nil {
 		authSessionEnd(c)
 		if c.Request.URL.Path == "/" {
 			request.AbortRedirect(c, "/login")
@@ -90,7 +100,7 @@ func Authorize(c *gin.Context) {
 		return
 	}
 
-	tokenStr, err := c.Cookie("token")
+	tokenStr, err := request.Cookie(c.Request, "token")
 	if err != nil {
 		if !constants.WebStrict {
 			c.Set("validated", false)
@@ -106,89 +116,56 @@ func Authorize(c *gin.Context) {
 		return
 	}
 
-	tokenByt, err := base64.URLEncoding.DecodeString(tokenStr)
-	if err != nil {
-		authSessionEnd(c)
-		if c.Request.URL.Path == "/" {
-			request.AbortRedirect(c, "/login")
-		} else {
-			request.AbortWithStatus(c, 401, "Failed to decode token")
-		}
-		return
-	}
-
-	if len(tokenByt) < 28 {
+	token, reason := openToken(tokenStr)
+	if reason != "" {
 		authSessionEnd(c)
 		if c.Request.URL.Path == "/" {
 			request.AbortRedirect(c, "/login")
 		} else {
-			request.AbortWithStatus(c, 401, "Token length invalid")
+			request.AbortWithStatus(c, 401, reason)
 		}
 		return
 	}
 
-	var nonce [24]byte
-	copy(nonce[:], tokenByt[:24])
-	encByt := tokenByt[24:]
-
-	decByt, ok := secretbox.Open(nil, encByt, &nonce, constants.WebSecret)
-	if !ok {
-		authSessionEnd(c)
-		if c.Request.URL.Path == "/" {
-			request.AbortRedirect(c, "/login")
-		} else {
-			request.AbortWithStatus(c, 401, "Failed to decrypt token")
-		}
-		return
-	}
-
-	token := &Token{}
-
-	err = json.Unmarshal(decByt, token)
-	if err != nil {
-		authSessionEnd(c)
-		if c.Request.URL.Path == "/" {
-			request.AbortRedirect(c, "/login")
-		} else {
-			request.AbortWithStatus(c, 401, "Failed to unmarshal token")
-		}
-		return
-	}
+	tokenTtl := time.Unix(token.Ttl, 0)
+	tokenSince := time.Since(tokenTtl)
 
-	if token.Id == "" {
+	if tokenSince < -730*time.Hour {
 		authSessionEnd(c)
 		if c.Request.URL.Path == "/" {
 			request.AbortRedirect(c, "/login")
 		} else {
-			request.AbortWithStatus(c, 401, "Token id invalid")
+			request.AbortWithStatus(c, 401, "Token session timestamp invalid")
 		}
 		return
 	}
 
-	tokenTtl := time.Unix(token.Ttl, 0)
-	tokenSince := time.Since(tokenTtl)
+	if tokenSince > 0 || (token.Expires != 0 &&
+		time.Now().Unix() > token.Expires) {
 
-	if tokenSince < -730*time.Hour {
 		authSessionEnd(c)
 		if c.Request.URL.Path == "/" {
 			request.AbortRedirect(c, "/login")
 		} else {
-			request.AbortWithStatus(c, 401, "Token session timestamp invalid")
+			request.AbortWithStatus(c, 401, "Token session expired")
 		}
 		return
 	}
 
-	if tokenSince > 0 {
+	if !checkBinding(c, token) {
 		authSessionEnd(c)
 		if c.Request.URL.Path == "/" {
 			request.AbortRedirect(c, "/login")
 		} else {
-			request.AbortWithStatus(c, 401, "Token session expired")
+			request.AbortWithStatus(c, 401, "Token binding mismatch")
 		}
 		return
 	}
 
 	c.Set("validated", true)
+	c.Set("token", token)
+
+	renewToken(c, token)
 }
 
 func Redirect(c *gin.Context) {
@@ -219,185 +196,227 @@ func Register(engine *gin.Engine) {
 	engine.Use(Limiter)
 	engine.Use(Recovery)
 	engine.Use(Redirect)
+	engine.Use(Headers)
+	engine.Use(Signature)
+
+	initHeaders()
+	nonces = newNonceCache(constants.Api.NonceCacheSize)
+	unverifiedNonces = newNonceCache(constants.Api.NonceCacheSize)
 
 	openAuth := engine.Group("")
 	openAuth.Use(Unauthorize)
 
-	authGroup := engine.Group("")
-	authGroup.Use(Authorize)
-
-	authGroup.GET("/admin", adminGet)
-	authGroup.GET("/admin/:admin_id", adminGet)
-	authGroup.PUT("/admin/:admin_id", adminPut)
-	authGroup.POST("/admin", adminPost)
-	authGroup.DELETE("/admin/:admin_id", adminDelete)
-	authGroup.GET("/admin/:admin_id/audit", adminAuditGet)
-
-	openAuth.POST("/auth/session", authSessionPost)
-	openAuth.DELETE("/auth/session", authSessionDelete)
-	authGroup.GET("/state", authStateGet)
-
-	authGroup.GET("/event", eventGet)
-	authGroup.GET("/event/:cursor", eventGet)
-
-	authGroup.GET("/device/unregistered", deviceUnregisteredGet)
-	authGroup.PUT("/device/register/:org_id/:user_id/:device_id",
+	adminUiOpen := engine.Group("")
+	adminUiOpen.Use(Policy(policy.AdminUi))
+	adminUiOpen.Use(RequireClientCert)
+	adminUiOpen.Use(Unauthorize)
+
+	adminUiAuth := engine.Group("")
+	adminUiAuth.Use(Policy(policy.AdminUi))
+	adminUiAuth.Use(Authorize)
+	adminUiAuth.Use(Csrf)
+
+	adminApiOpen := engine.Group("")
+	adminApiOpen.Use(Policy(policy.AdminApi))
+	adminApiOpen.Use(RequireClientCert)
+	adminApiOpen.Use(Unauthorize)
+
+	adminApiAuth := engine.Group("")
+	adminApiAuth.Use(Policy(policy.AdminApi))
+	adminApiAuth.Use(Authorize)
+	adminApiAuth.Use(Csrf)
+
+	keyOpen := engine.Group("")
+	keyOpen.Use(Policy(policy.Key))
+	keyOpen.Use(Unauthorize)
+
+	linkStateOpen := engine.Group("")
+	linkStateOpen.Use(Policy(policy.LinkState))
+	linkStateOpen.Use(Unauthorize)
+
+	setupOpen := engine.Group("")
+	setupOpen.Use(Policy(policy.Setup))
+	setupOpen.Use(Unauthorize)
+
+	adminApiAuth.GET("/admin", adminGet)
+	adminApiAuth.GET("/admin/:admin_id", adminGet)
+	adminApiAuth.PUT("/admin/:admin_id", adminPut)
+	adminApiAuth.POST("/admin", adminPost)
+	adminApiAuth.DELETE("/admin/:admin_id", adminDelete)
+	adminApiAuth.GET("/admin/:admin_id/audit", adminAuditGet)
+
+	adminApiOpen.POST("/auth/session", authSessionPost)
+	adminApiOpen.DELETE("/auth/session", authSessionDelete)
+	adminApiAuth.GET("/state", authStateGet)
+
+	adminApiAuth.GET("/event", eventGet)
+	adminApiAuth.GET("/event/:cursor", eventGet)
+
+	adminApiAuth.GET("/device/unregistered", deviceUnregisteredGet)
+	adminApiAuth.PUT("/device/register/:org_id/:user_id/:device_id",
 		deviceRegisterPut)
-	authGroup.DELETE("/device/register/:org_id/:user_id/:device_id",
+	adminApiAuth.DELETE("/device/register/:org_id/:user_id/:device_id",
 		deviceRegisterDelete)
 
-	authGroup.GET("/host", hostGet)
-	authGroup.GET("/host/:host_id", hostGet)
-	authGroup.PUT("/host/:host_id", hostPut)
-	authGroup.DELETE("/host/:host_id", hostDelete)
-	authGroup.GET("/host/:host_id/usage/:period", hostUsageGet)
-
-	authGroup.GET("/data/:org_id/:user_id", dataKeyGet)
-	authGroup.GET("/data/:org_id/:user_id/:server_id", dataServerKeyGet)
-
-	openAuth.GET("/key/:param1", keyGet)
-	openAuth.GET("/key/:param1/:param2", keyGet)
-	openAuth.GET("/key/:param1/:param2/:param3", keyGet)
-	openAuth.GET("/key/:param1/:param2/:param3/:param4", keyGet)
-	openAuth.GET("/key/:param1/:param2/:param3/:param4/:param5", keyGet)
-	openAuth.POST("/key/duo", keyDuoPost)
-	openAuth.POST("/key/yubico", keyYubicoPost)
-	openAuth.PUT("/key_pin/:key_id", keyPinPut)
-	openAuth.GET("/k/:short_code", keyShortGet)
-	openAuth.DELETE("/k/:short_code", keyShortDelete)
-	openAuth.GET("/ku/:short_code", keyApiShortGet)
-	openAuth.POST("/key/wg/:org_id/:user_id/:server_id", keyWgPost)
-	openAuth.PUT("/key/wg/:org_id/:user_id/:server_id", keyWgPut)
-	openAuth.POST("/key/ovpn/:org_id/:user_id/:server_id", keyOvpnPost)
-	openAuth.POST("/key/ovpn_wait/:org_id/:user_id/:server_id",
+	adminApiAuth.GET("/host", hostGet)
+	adminApiAuth.GET("/host/:host_id", hostGet)
+	adminApiAuth.PUT("/host/:host_id", hostPut)
+	adminApiAuth.DELETE("/host/:host_id", hostDelete)
+	adminApiAuth.GET("/host/:host_id/usage/:period", hostUsageGet)
+
+	adminApiAuth.GET("/data/:org_id/:user_id", dataKeyGet)
+	adminApiAuth.GET("/data/:org_id/:user_id/:server_id", dataServerKeyGet)
+
+	keyOpen.GET("/key/:param1", keyGet)
+	keyOpen.GET("/key/:param1/:param2", keyGet)
+	keyOpen.GET("/key/:param1/:param2/:param3", keyGet)
+	keyOpen.GET("/key/:param1/:param2/:param3/:param4", keyGet)
+	keyOpen.GET("/key/:param1/:param2/:param3/:param4/:param5", keyGet)
+	keyOpen.POST("/key/duo", keyDuoPost)
+	keyOpen.POST("/key/yubico", keyYubicoPost)
+	keyOpen.PUT("/key_pin/:key_id", keyPinPut)
+	keyOpen.GET("/k/:short_code", keyShortGet)
+	keyOpen.DELETE("/k/:short_code", keyShortDelete)
+	keyOpen.GET("/ku/:short_code", keyApiShortGet)
+	keyOpen.POST("/key/wg/:org_id/:user_id/:server_id", keyWgPost)
+	keyOpen.PUT("/key/wg/:org_id/:user_id/:server_id", keyWgPut)
+	keyOpen.POST("/key/ovpn/:org_id/:user_id/:server_id", keyOvpnPost)
+	keyOpen.POST("/key/ovpn_wait/:org_id/:user_id/:server_id",
 		keyOvpnWaitPost)
-	openAuth.POST("/key/wg_wait/:org_id/:user_id/:server_id",
+	keyOpen.POST("/key/wg_wait/:org_id/:user_id/:server_id",
 		keyWgWaitPost)
-	openAuth.POST("/sso/authenticate", ssoAuthenticatePost)
-	openAuth.GET("/sso/request", ssoRequestGet)
-	openAuth.GET("/sso/callback", ssoCallbackGet)
-	openAuth.POST("/sso/duo", ssoDuoPost)
-	openAuth.POST("/sso/yubico", ssoYubicoPost)
-
-	authGroup.GET("/link", linkGet)
-	authGroup.POST("/link", linkPost)
-	openAuth.PUT("/link/state", linkStatePut)
-	openAuth.DELETE("/link/state", linkStateDelete)
-	authGroup.PUT("/link/:link_id", linkPut)
-	authGroup.DELETE("/link/:link_id", linkDelete)
-	authGroup.GET("/link/:link_id/location", linkLocationGet)
-	authGroup.POST("/link/:link_id/location", linkLocationPost)
-	authGroup.PUT("/link/:link_id/location/:location_id", linkLocationPut)
-	authGroup.DELETE("/link/:link_id/location/:location_id",
+	keyOpen.POST("/sso/authenticate", ssoAuthenticatePost)
+	keyOpen.GET("/sso/request", ssoRequestGet)
+	keyOpen.GET("/sso/callback", ssoCallbackGet)
+	keyOpen.POST("/sso/duo", ssoDuoPost)
+	keyOpen.POST("/sso/yubico", ssoYubicoPost)
+
+	adminApiAuth.GET("/link", linkGet)
+	adminApiAuth.POST("/link", linkPost)
+	linkStateOpen.PUT("/link/state", linkStatePut)
+	linkStateOpen.DELETE("/link/state", linkStateDelete)
+	adminApiAuth.PUT("/link/:link_id", linkPut)
+	adminApiAuth.DELETE("/link/:link_id", linkDelete)
+	adminApiAuth.GET("/link/:link_id/location", linkLocationGet)
+	adminApiAuth.POST("/link/:link_id/location", linkLocationPost)
+	adminApiAuth.PUT("/link/:link_id/location/:location_id", linkLocationPut)
+	adminApiAuth.DELETE("/link/:link_id/location/:location_id",
 		linkLocationDelete)
-	authGroup.POST("/link/:link_id/location/:location_id/route",
+	adminApiAuth.POST("/link/:link_id/location/:location_id/route",
 		linkLocationRoutePost)
-	authGroup.PUT("/link/:link_id/location/:location_id/route/:route_id",
+	adminApiAuth.PUT("/link/:link_id/location/:location_id/route/:route_id",
 		linkLocationRoutePut)
-	authGroup.DELETE("/link/:link_id/location/:location_id/route/:route_id",
+	adminApiAuth.DELETE("/link/:link_id/location/:location_id/route/:route_id",
 		linkLocationRouteDelete)
-	authGroup.GET("/link/:link_id/location/:location_id/host/:host_id/uri",
+	adminApiAuth.GET("/link/:link_id/location/:location_id/host/:host_id/uri",
 		linkLocationHostUriGet)
-	authGroup.GET("/link/:link_id/location/:location_id/host/:host_id/conf",
+	adminApiAuth.GET("/link/:link_id/location/:location_id/host/:host_id/conf",
 		linkLocationHostConfGet)
-	authGroup.POST("/link/:link_id/location/:location_id/host",
+	adminApiAuth.POST("/link/:link_id/location/:location_id/host",
 		linkLocationHostPost)
-	authGroup.PUT("/link/:link_id/location/:location_id/host/:host_id",
+	adminApiAuth.PUT("/link/:link_id/location/:location_id/host/:host_id",
 		linkLocationHostPut)
-	authGroup.DELETE("/link/:link_id/location/:location_id/host/:host_id",
+	adminApiAuth.DELETE("/link/:link_id/location/:location_id/host/:host_id",
 		linkLocationHostDelete)
-	authGroup.POST("/link/:link_id/location/:location_id/peer",
+	adminApiAuth.POST("/link/:link_id/location/:location_id/peer",
 		linkLocationPeerPost)
-	authGroup.DELETE("/link/:link_id/location/:location_id/peer/:peer_id",
+	adminApiAuth.DELETE("/link/:link_id/location/:location_id/peer/:peer_id",
 		linkLocationPeerDelete)
-	authGroup.POST("/link/:link_id/location/:location_id/transit",
+	adminApiAuth.POST("/link/:link_id/location/:location_id/transit",
 		linkLocationTransitPost)
-	authGroup.DELETE(
+	adminApiAuth.DELETE(
 		"/link/:link_id/location/:location_id/transit/:transit_id",
 		linkLocationTransitDelete)
 
-	authGroup.GET("/log", logGet)
-	authGroup.GET("/logs", logsGet)
+	adminApiAuth.GET("/log", logGet)
+	adminApiAuth.GET("/logs", logsGet)
 
-	authGroup.GET("/organization", orgGet)
-	authGroup.GET("/organization/:org_id", orgGet)
-	authGroup.POST("/organization", orgPost)
-	authGroup.PUT("/organization/:org_id", orgPut)
-	authGroup.DELETE("/organization/:org_id", orgDelete)
+	adminApiAuth.GET("/organization", orgGet)
+	adminApiAuth.GET("/organization/:org_id", orgGet)
+	adminApiAuth.POST("/organization", orgPost)
+	adminApiAuth.PUT("/organization/:org_id", orgPut)
+	adminApiAuth.DELETE("/organization/:org_id", orgDelete)
 
 	openAuth.GET("/ping", pingGet)
 	openAuth.GET("/check", checkGet)
 
 	openAuth.GET("/robots.txt", robotsGet)
 
-	authGroup.GET("/server", serverGet)
-	authGroup.GET("/server/:server_id", serverGet)
-	authGroup.POST("/server", serverPost)
-	authGroup.PUT("/server/:server_id", serverPut)
-	authGroup.DELETE("/server/:server_id", serverDelete)
-	authGroup.GET("/server/:server_id/organization", serverOrgGet)
-	authGroup.PUT("/server/:server_id/organization/:org_id", serverOrgPut)
-	authGroup.DELETE("/server/:server_id/organization/:org_id",
+	if constants.Headers.CspReport {
+		openAuth.POST("/csp-report", cspReportPost)
+	}
+
+	adminApiAuth.GET("/server", serverGet)
+	adminApiAuth.GET("/server/:server_id", serverGet)
+	adminApiAuth.POST("/server", serverPost)
+	adminApiAuth.PUT("/server/:server_id", serverPut)
+	adminApiAuth.DELETE("/server/:server_id", serverDelete)
+	adminApiAuth.GET("/server/:server_id/organization", serverOrgGet)
+	adminApiAuth.PUT("/server/:server_id/organization/:org_id", serverOrgPut)
+	adminApiAuth.DELETE("/server/:server_id/organization/:org_id",
 		serverOrgDelete)
-	authGroup.GET("/server/:server_id/route", serverRouteGet)
-	authGroup.POST("/server/:server_id/route", serverRoutePost)
-	authGroup.POST("/server/:server_id/routes", serverRoutesPost)
-	authGroup.PUT("/server/:server_id/route/:route_net", serverRoutePut)
-	authGroup.DELETE("/server/:server_id/route/:route_net", serverRouteDelete)
-	authGroup.GET("/server/:server_id/host", serverHostGet)
-	authGroup.PUT("/server/:server_id/host/:host_id", serverHostPut)
-	authGroup.DELETE("/server/:server_id/host/:host_id", serverHostDelete)
-	authGroup.GET("/server/:server_id/link", serverLinkGet)
-	authGroup.PUT("/server/:server_id/link/:link_id", serverLinkPut)
-	authGroup.DELETE("/server/:server_id/link/:link_id", serverLinkDelete)
-	authGroup.PUT("/server/:server_id/operation/:operation",
+	adminApiAuth.GET("/server/:server_id/route", serverRouteGet)
+	adminApiAuth.POST("/server/:server_id/route", serverRoutePost)
+	adminApiAuth.POST("/server/:server_id/routes", serverRoutesPost)
+	adminApiAuth.PUT("/server/:server_id/route/:route_net", serverRoutePut)
+	adminApiAuth.DELETE("/server/:server_id/route/:route_net",
+		serverRouteDelete)
+	adminApiAuth.GET("/server/:server_id/host", serverHostGet)
+	adminApiAuth.PUT("/server/:server_id/host/:host_id", serverHostPut)
+	adminApiAuth.DELETE("/server/:server_id/host/:host_id", serverHostDelete)
+	adminApiAuth.GET("/server/:server_id/link", serverLinkGet)
+	adminApiAuth.PUT("/server/:server_id/link/:link_id", serverLinkPut)
+	adminApiAuth.DELETE("/server/:server_id/link/:link_id", serverLinkDelete)
+	adminApiAuth.PUT("/server/:server_id/operation/:operation",
 		serverOperationPut)
-	authGroup.GET("/server/:server_id/output", serverOutputGet)
-	authGroup.DELETE("/server/:server_id/output", serverOutputDelete)
-	authGroup.GET("/server/:server_id/link_output", serverLinkOutputGet)
-	authGroup.DELETE("/server/:server_id/link_output", serverLinkOutputDelete)
-	authGroup.GET("/server/:server_id/bandwidth/:period", serverBandwidthGet)
-
-	authGroup.GET("/settings", settingsGet)
-	authGroup.PUT("/settings", settingsPut)
-	authGroup.GET("/settings/zones", settingsZonesGet)
-
-	openAuth.GET("/setup", setupGet)
-	openAuth.GET("/upgrade", upgradeGet)
-	openAuth.GET("/setup/s/fredoka-one.eot", setupFredokaEotStaticGet)
-	openAuth.GET("/setup/s/ubuntu-bold.eot", setupUbuntuEotStaticGet)
-	openAuth.GET("/setup/s/fredoka-one.woff", setupFredokaWoffStaticGet)
-	openAuth.GET("/setup/s/ubuntu-bold.woff", setupUbuntuWoffStaticGet)
-	openAuth.PUT("/setup/mongodb", setupMongoPut)
-	openAuth.GET("/setup/upgrade", setupUpgradeGet)
-	openAuth.GET("/success", successGet)
-
-	authGroup.GET("/s/*path", staticPathGet)
-	openAuth.GET("/fredoka-one.eot", fredokaEotStaticGet)
-	openAuth.GET("/ubuntu-bold.eot", ubuntuEotStaticGet)
-	openAuth.GET("/fredoka-one.woff", fredokaWoffStaticGet)
-	openAuth.GET("/ubuntu-bold.woff", ubuntuWoffStaticGet)
-	openAuth.GET("/logo.png", logoStaticGet)
-	authGroup.GET("/", rootGet)
-	openAuth.GET("/login", loginGet)
-
-	authGroup.GET("/status", statusGet)
-
-	authGroup.GET("/subscription", subscriptionGet)
-	authGroup.GET("/subscription/styles/:plan/:ver", subscriptionStylesGet)
-	authGroup.POST("/subscription", subscriptionPost)
-	authGroup.PUT("/subscription", subscriptionPut)
-	authGroup.DELETE("/subscription", subscriptionDelete)
-
-	authGroup.GET("/user/:org_id", usersGet)
-	authGroup.GET("/user/:org_id/:user_id", userGet)
-	authGroup.POST("/user/:org_id", userPost)
-	authGroup.POST("/user/:org_id/multi", userMultiPost)
-	authGroup.PUT("/user/:org_id/:user_id", userPut)
-	authGroup.DELETE("/user/:org_id/:user_id", userDelete)
-	authGroup.PUT("/user/:org_id/:user_id/otp_secret", userOtpSecretPut)
-	authGroup.GET("/user/:org_id/:user_id/audit", userAuditGet)
-	authGroup.PUT("/user/:org_id/:user_id/device/:device_id", userDevicePut)
-	authGroup.DELETE("/user/:org_id/:user_id/device/:device_id",
+	adminApiAuth.GET("/server/:server_id/output", serverOutputGet)
+	adminApiAuth.DELETE("/server/:server_id/output", serverOutputDelete)
+	adminApiAuth.GET("/server/:server_id/link_output", serverLinkOutputGet)
+	adminApiAuth.DELETE("/server/:server_id/link_output",
+		serverLinkOutputDelete)
+	adminApiAuth.GET("/server/:server_id/bandwidth/:period",
+		serverBandwidthGet)
+
+	adminApiAuth.GET("/settings", settingsGet)
+	adminApiAuth.PUT("/settings", settingsPut)
+	adminApiAuth.GET("/settings/zones", settingsZonesGet)
+
+	setupOpen.GET("/setup", setupGet)
+	setupOpen.GET("/upgrade", upgradeGet)
+	setupOpen.GET("/setup/s/fredoka-one.eot", setupFredokaEotStaticGet)
+	setupOpen.GET("/setup/s/ubuntu-bold.eot", setupUbuntuEotStaticGet)
+	setupOpen.GET("/setup/s/fredoka-one.woff", setupFredokaWoffStaticGet)
+	setupOpen.GET("/setup/s/ubuntu-bold.woff", setupUbuntuWoffStaticGet)
+	setupOpen.PUT("/setup/mongodb", setupMongoPut)
+	setupOpen.GET("/setup/upgrade", setupUpgradeGet)
+	setupOpen.GET("/success", successGet)
+
+	adminUiAuth.GET("/s/*path", staticPathGet)
+	adminUiOpen.GET("/fredoka-one.eot", fredokaEotStaticGet)
+	adminUiOpen.GET("/ubuntu-bold.eot", ubuntuEotStaticGet)
+	adminUiOpen.GET("/fredoka-one.woff", fredokaWoffStaticGet)
+	adminUiOpen.GET("/ubuntu-bold.woff", ubuntuWoffStaticGet)
+	adminUiOpen.GET("/logo.png", logoStaticGet)
+	adminUiAuth.GET("/", rootGet)
+	adminUiOpen.GET("/login", loginGet)
+
+	adminApiAuth.GET("/status", statusGet)
+
+	adminApiAuth.GET("/subscription", subscriptionGet)
+	adminApiAuth.GET("/subscription/styles/:plan/:ver", subscriptionStylesGet)
+	adminApiAuth.POST("/subscription", subscriptionPost)
+	adminApiAuth.PUT("/subscription", subscriptionPut)
+	adminApiAuth.DELETE("/subscription", subscriptionDelete)
+
+	adminApiAuth.GET("/user/:org_id", usersGet)
+	adminApiAuth.GET("/user/:org_id/:user_id", userGet)
+	adminApiAuth.POST("/user/:org_id", userPost)
+	adminApiAuth.POST("/user/:org_id/multi", userMultiPost)
+	adminApiAuth.PUT("/user/:org_id/:user_id", userPut)
+	adminApiAuth.DELETE("/user/:org_id/:user_id", userDelete)
+	adminApiAuth.PUT("/user/:org_id/:user_id/otp_secret", userOtpSecretPut)
+	adminApiAuth.GET("/user/:org_id/:user_id/audit", userAuditGet)
+	adminApiAuth.PUT("/user/:org_id/:user_id/device/:device_id", userDevicePut)
+	adminApiAuth.DELETE("/user/:org_id/:user_id/device/:device_id",
 		userDeviceDelete)
 }
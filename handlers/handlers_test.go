@@ -0,0 +1,232 @@
+package handlers_test
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/pritunl/pritunl-web/utils"
+)
+
+const (
+	groupOpen = "open"
+	groupAuth = "auth"
+	clientIp  = "198.51.100.7"
+)
+
+const paramValue = "id<x>'1\""
+
+type routeCase struct {
+	Group    string
+	Body     string
+	Local    bool
+	RawQuery bool
+}
+
+var routeCases = map[string]routeCase{
+	"GET /ping":             {Group: groupOpen},
+	"GET /check":            {Group: groupOpen},
+	"GET /robots.txt":       {Group: groupOpen, Local: true},
+	"GET /fredoka-one.eot":  {Group: groupOpen},
+	"GET /ubuntu-bold.eot":  {Group: groupOpen},
+	"GET /fredoka-one.woff": {Group: groupOpen},
+	"GET /ubuntu-bold.woff": {Group: groupOpen},
+	"GET /logo.png":         {Group: groupOpen},
+
+	"GET /login":           {Group: groupOpen},
+	"POST /auth/session":   {Group: groupOpen},
+	"DELETE /auth/session": {Group: groupOpen},
+
+	"GET /key/:param1":                                 {Group: groupOpen},
+	"GET /key/:param1/:param2":                         {Group: groupOpen},
+	"GET /key/:param1/:param2/:param3":                 {Group: groupOpen},
+	"GET /key/:param1/:param2/:param3/:param4":         {Group: groupOpen},
+	"GET /key/:param1/:param2/:param3/:param4/:param5": {Group: groupOpen},
+	"POST /key/duo":                                    {Group: groupOpen},
+	"POST /key/yubico":                                 {Group: groupOpen},
+	"PUT /key_pin/:key_id":                             {Group: groupOpen},
+	"GET /k/:short_code":                               {Group: groupOpen},
+	"DELETE /k/:short_code":                            {Group: groupOpen},
+	"GET /ku/:short_code":                              {Group: groupOpen},
+	"POST /key/wg/:org_id/:user_id/:server_id":         {Group: groupOpen},
+	"PUT /key/wg/:org_id/:user_id/:server_id":          {Group: groupOpen},
+	"POST /key/ovpn/:org_id/:user_id/:server_id":       {Group: groupOpen},
+	"POST /key/ovpn_wait/:org_id/:user_id/:server_id":  {Group: groupOpen},
+	"POST /key/wg_wait/:org_id/:user_id/:server_id":    {Group: groupOpen},
+	"POST /sso/authenticate":                           {Group: groupOpen},
+	"GET /sso/request":                                 {Group: groupOpen},
+	"GET /sso/callback":                                {Group: groupOpen, RawQuery: true},
+	"POST /sso/duo":                                    {Group: groupOpen},
+	"POST /sso/yubico":                                 {Group: groupOpen},
+	"PUT /link/state":                                  {Group: groupOpen},
+	"DELETE /link/state":                               {Group: groupOpen},
+	"GET /setup":                                       {Group: groupOpen},
+	"GET /upgrade":                                     {Group: groupOpen},
+	"GET /setup/s/fredoka-one.eot":                     {Group: groupOpen},
+	"GET /setup/s/ubuntu-bold.eot":                     {Group: groupOpen},
+	"GET /setup/s/fredoka-one.woff":                    {Group: groupOpen},
+	"GET /setup/s/ubuntu-bold.woff":                    {Group: groupOpen},
+	"PUT /setup/mongodb":                               {Group: groupOpen},
+	"GET /setup/upgrade":                               {Group: groupOpen},
+	"GET /success":                                     {Group: groupOpen},
+	"POST /server/:server_id/routes":                   {Group: groupAuth, Body: "[]"},
+	"POST /user/:org_id/multi":                         {Group: groupAuth, Body: "[]"},
+}
+
+func getRouteCase(method, path string) routeCase {
+	rc, ok := routeCases[method+" "+path]
+	if !ok {
+		rc = routeCase{
+			Group: groupAuth,
+		}
+	}
+	if rc.Body == "" && method != "GET" {
+		rc.Body = "{}"
+	}
+	return rc
+}
+
+func expandRoute(path string) (target, backend string) {
+	targetParts := []string{}
+	backendParts := []string{}
+
+	for _, part := range strings.Split(path, "/") {
+		if strings.HasPrefix(part, ":") {
+			targetParts = append(targetParts, url.PathEscape(paramValue))
+			backendParts = append(backendParts,
+				utils.FilterStr(paramValue, 128))
+		} else if strings.HasPrefix(part, "*") {
+			targetParts = append(targetParts, "app", "main.js")
+			backendParts = append(backendParts, "app", "main.js")
+		} else {
+			targetParts = append(targetParts, part)
+			backendParts = append(backendParts, part)
+		}
+	}
+
+	target = strings.Join(targetParts, "/")
+	backend = strings.Join(backendParts, "/")
+	return
+}
+
+func TestRouteCasesRegistered(t *testing.T) {
+	h := newHarness(t)
+
+	registered := map[string]bool{}
+	for _, route := range h.router.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+
+	for key := range routeCases {
+		if !registered[key] {
+			t.Errorf("route case %q is not registered", key)
+		}
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	h := newHarness(t)
+
+	for _, route := range h.router.Routes() {
+		rc := getRouteCase(route.Method, route.Path)
+		target, backendPath := expandRoute(route.Path)
+
+		t.Run(route.Method+" "+route.Path, func(t *testing.T) {
+			resp := h.Do(route.Method, target+"?injected=1", rc.Body,
+				rc.Group == groupAuth)
+
+			reqs := h.backend.Requests()
+			if rc.Local {
+				if len(reqs) != 0 {
+					t.Fatalf("expected no backend request, got %d",
+						len(reqs))
+				}
+				return
+			}
+			if len(reqs) != 1 {
+				t.Fatalf("expected 1 backend request, got %d (status %d)",
+					len(reqs), resp.Code)
+			}
+			req := reqs[0]
+
+			if req.Method != route.Method {
+				t.Errorf("backend method %q, expected %q",
+					req.Method, route.Method)
+			}
+
+			if req.Path != backendPath {
+				t.Errorf("backend path %q, expected %q",
+					req.Path, backendPath)
+			}
+
+			if strings.ContainsAny(req.Path, "<>'\"") {
+				t.Errorf("backend path %q contains unfiltered characters",
+					req.Path)
+			}
+
+			_, injected := req.Query["injected"]
+			if injected != rc.RawQuery {
+				t.Errorf("backend query %q forwarded unexpectedly",
+					req.Query.Encode())
+			}
+
+			validated := "false"
+			if rc.Group == groupAuth {
+				validated = "true"
+			}
+			if req.Header.Get("PR-Validated") != validated {
+				t.Errorf("PR-Validated %q, expected %q",
+					req.Header.Get("PR-Validated"), validated)
+			}
+
+			if req.Header.Get("PR-Forwarded-For") != clientIp {
+				t.Errorf("PR-Forwarded-For %q, expected %q",
+					req.Header.Get("PR-Forwarded-For"), clientIp)
+			}
+
+			if req.Header.Get("PR-Forwarded-Url") == "" {
+				t.Error("PR-Forwarded-Url missing")
+			}
+
+			if resp.Code != 200 {
+				t.Errorf("response status %d, expected 200", resp.Code)
+			}
+		})
+	}
+}
+
+func TestRoutesUnauthorized(t *testing.T) {
+	h := newHarness(t)
+
+	for _, route := range h.router.Routes() {
+		rc := getRouteCase(route.Method, route.Path)
+		if rc.Group != groupAuth {
+			continue
+		}
+		target, _ := expandRoute(route.Path)
+
+		t.Run(route.Method+" "+route.Path, func(t *testing.T) {
+			resp := h.Do(route.Method, target, rc.Body, false)
+
+			if route.Path == "/" {
+				if resp.Code != 302 {
+					t.Errorf("response status %d, expected 302", resp.Code)
+				}
+			} else if resp.Code != 401 {
+				t.Errorf("response status %d, expected 401", resp.Code)
+			}
+
+			for _, req := range h.backend.Requests() {
+				if req.Method != "DELETE" || req.Path != "/auth/session" {
+					t.Errorf("unexpected backend request %s %s",
+						req.Method, req.Path)
+				}
+
+				if req.Header.Get("PR-Validated") != "false" {
+					t.Errorf("PR-Validated %q, expected \"false\"",
+						req.Header.Get("PR-Validated"))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dropbox/godropbox/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-web/constants"
+	"github.com/pritunl/pritunl-web/errortypes"
+	"github.com/pritunl/pritunl-web/request"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	cspReportMax     = 8192
+	cspReportLimit   = 60
+	cspReportClients = 10000
+)
+
+var (
+	baseHeaders     map[string]string
+	headersOverride map[string]map[string]string
+	cspReportLock   = sync.Mutex{}
+	cspReportWindow time.Time
+	cspReportCounts = map[string]int{}
+)
+
+func contentSecurityPolicy() string {
+	csp := strings.TrimSpace(constants.Headers.ContentSecurityPolicy)
+	if csp == "" {
+		return ""
+	}
+	csp = strings.TrimSuffix(csp, ";")
+
+	if !strings.Contains(csp, "frame-ancestors") {
+		switch strings.ToUpper(constants.Headers.FrameOptions) {
+		case "DENY":
+			csp += "; frame-ancestors 'none'"
+		case "SAMEORIGIN":
+			csp += "; frame-ancestors 'self'"
+		}
+	}
+
+	if constants.Headers.CspReport && !strings.Contains(csp, "report-uri") {
+		csp += "; report-uri /csp-report"
+	}
+
+	return csp
+}
+
+func initHeaders() {
+	baseHeaders = map[string]string{
+		"X-Content-Type-Options":  "nosniff",
+		"Referrer-Policy":         constants.Headers.ReferrerPolicy,
+		"X-Frame-Options":         constants.Headers.FrameOptions,
+		"Permissions-Policy":      constants.Headers.PermissionsPolicy,
+		"Content-Security-Policy": contentSecurityPolicy(),
+	}
+
+	headersOverride = map[string]map[string]string{}
+	for path, override := range constants.Headers.Override {
+		canonical := map[string]string{}
+		for key, val := range override {
+			canonical[http.CanonicalHeaderKey(key)] = val
+		}
+		headersOverride[path] = canonical
+	}
+
+	if constants.Ssl && constants.Headers.HstsMaxAge > 0 {
+		hsts := "max-age=" + strconv.Itoa(constants.Headers.HstsMaxAge)
+		if constants.Headers.HstsIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if constants.Headers.HstsPreload {
+			hsts += "; preload"
+		}
+		baseHeaders["Strict-Transport-Security"] = hsts
+	}
+}
+
+func Headers(c *gin.Context) {
+	header := c.Writer.Header()
+	override := headersOverride[c.FullPath()]
+	edgeHeaders := map[string]bool{}
+
+	for key, val := range baseHeaders {
+		if overrideVal, ok := override[key]; ok {
+			val = overrideVal
+		}
+		if val != "" {
+			header.Set(key, val)
+			edgeHeaders[key] = true
+		}
+	}
+
+	for key, val := range override {
+		if _, ok := baseHeaders[key]; !ok && val != "" {
+			header.Set(key, val)
+			edgeHeaders[key] = true
+		}
+	}
+
+	c.Set("edge_headers", edgeHeaders)
+}
+
+func cspReportAllow(clientIp string) bool {
+	now := time.Now()
+
+	cspReportLock.Lock()
+	defer cspReportLock.Unlock()
+
+	if now.Sub(cspReportWindow) >= time.Minute {
+		cspReportWindow = now
+		cspReportCounts = map[string]int{}
+	}
+
+	count, ok := cspReportCounts[clientIp]
+	if !ok && len(cspReportCounts) >= cspReportClients {
+		return false
+	}
+
+	if count >= cspReportLimit {
+		return false
+	}
+	cspReportCounts[clientIp] = count + 1
+
+	return true
+}
+
+func cspReportPost(c *gin.Context) {
+	if !cspReportAllow(request.ClientIp(c)) {
+		c.AbortWithStatus(429)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, cspReportMax+1))
+	if err != nil {
+		c.AbortWithError(400, err)
+		return
+	}
+
+	if len(body) > cspReportMax {
+		c.AbortWithStatus(413)
+		return
+	}
+
+	report := &bytes.Buffer{}
+	err = json.Compact(report, body)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrap(err, "handlers: Failed to parse CSP report"),
+		}
+		c.AbortWithError(400, err)
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"client_ip": request.ClientIp(c),
+		"report":    report.String(),
+	}).Warn("handlers: Content security policy violation")
+
+	c.Status(204)
+}
@@ -0,0 +1,115 @@
+package handlers_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pritunl/pritunl-web/constants"
+)
+
+func (h *harness) PostCspReport(remoteIp, body string) int {
+	req := httptest.NewRequest("POST", "/csp-report",
+		strings.NewReader(body))
+	req.RemoteAddr = remoteIp + ":43210"
+	req.Header.Set("Content-Type", "application/csp-report")
+
+	resp := httptest.NewRecorder()
+	h.router.ServeHTTP(resp, req)
+	return resp.Code
+}
+
+func TestCspReport(t *testing.T) {
+	constants.Headers.CspReport = true
+	defer func() {
+		constants.Headers.CspReport = false
+	}()
+
+	h := newHarness(t)
+
+	post := func(body string) int {
+		return h.PostCspReport(clientIp, body)
+	}
+
+	report := `{"csp-report": {"blocked-uri": "inline"}}`
+
+	if code := post(report); code != 204 {
+		t.Fatalf("report status %d, expected 204", code)
+	}
+
+	if code := post("not json"); code != 400 {
+		t.Fatalf("invalid report status %d, expected 400", code)
+	}
+
+	large := `{"csp-report": "` + strings.Repeat("a", 10000) + `"}`
+	if code := post(large); code != 413 {
+		t.Fatalf("large report status %d, expected 413", code)
+	}
+
+	limited := false
+	for i := 0; i < 100; i++ {
+		if post(report) == 429 {
+			limited = true
+			break
+		}
+	}
+	if !limited {
+		t.Fatal("reports not rate limited")
+	}
+
+	if code := h.PostCspReport("198.51.100.8", report); code != 204 {
+		t.Fatalf("other client report status %d, expected 204", code)
+	}
+
+	if len(h.backend.Requests()) != 0 {
+		t.Fatal("report forwarded to backend")
+	}
+}
+
+func TestSecurityHeadersNotDuplicated(t *testing.T) {
+	constants.Headers.FrameOptions = "DENY"
+	defer func() {
+		constants.Headers.FrameOptions = ""
+	}()
+
+	h := newHarness(t)
+	h.backend.Header.Set("X-Frame-Options", "SAMEORIGIN")
+	h.backend.Header.Set("X-Backend", "1")
+
+	resp := h.Do("GET", "/settings", "", true)
+
+	frameOptions := resp.Header().Values("X-Frame-Options")
+	if len(frameOptions) != 1 || frameOptions[0] != "DENY" {
+		t.Fatalf("X-Frame-Options %q, expected edge value", frameOptions)
+	}
+
+	if resp.Header().Get("X-Backend") != "1" {
+		t.Fatal("backend header not forwarded")
+	}
+}
+
+func TestSecurityHeadersOverride(t *testing.T) {
+	override := map[string]map[string]string{
+		"/settings": {
+			"x-frame-options": "SAMEORIGIN",
+		},
+	}
+	constants.Headers.FrameOptions = "DENY"
+	constants.Headers.Override = override
+	defer func() {
+		constants.Headers.FrameOptions = ""
+		constants.Headers.Override = nil
+	}()
+
+	h := newHarness(t)
+
+	resp := h.Do("GET", "/settings", "", true)
+	if resp.Header().Get("X-Frame-Options") != "SAMEORIGIN" {
+		t.Fatalf("X-Frame-Options %q, expected override",
+			resp.Header().Get("X-Frame-Options"))
+	}
+
+	if _, ok := override["/settings"]["x-frame-options"]; !ok {
+		t.Fatal("configured override modified")
+	}
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-web/payload"
 	"github.com/pritunl/pritunl-web/request"
 	"github.com/pritunl/pritunl-web/utils"
 )
@@ -29,21 +30,7 @@ func hostGet(c *gin.Context) {
 	req.Do(c)
 }
 
-type hostPutData struct {
-	Name              string `json:"name"`
-	PublicAddress     string `json:"public_address"`
-	PublicAddress6    string `json:"public_address6"`
-	RoutedSubnet6     string `json:"routed_subnet6"`
-	RoutedSubnet6Wg   string `json:"routed_subnet6_wg"`
-	ProxyNdp          bool   `json:"proxy_ndp"`
-	LocalAddress      string `json:"local_address"`
-	LocalAddress6     string `json:"local_address6"`
-	LinkAddress       string `json:"link_address"`
-	SyncAddress       string `json:"sync_address"`
-	AvailabilityGroup string `json:"availability_group"`
-	Priority          int    `json:"priority"`
-	InstanceId        string `json:"instance_id"`
-}
+type hostPutData = payload.Host
 
 func hostPut(c *gin.Context) {
 	hostId := utils.FilterStr(c.Params.ByName("host_id"), 128)
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-web/payload"
 	"github.com/pritunl/pritunl-web/request"
 	"github.com/pritunl/pritunl-web/utils"
 )
@@ -24,19 +25,7 @@ func linkGet(c *gin.Context) {
 	req.Do(c)
 }
 
-type linkPostData struct {
-	Name           string `json:"name"`
-	Type           string `json:"type"`
-	Status         string `json:"status"`
-	Protocol       string `json:"protocol"`
-	WgPort         int    `json:"wg_port"`
-	Ipv6           bool   `json:"ipv6"`
-	HostCheck      bool   `json:"host_check"`
-	Action         string `json:"action"`
-	PreferredIke   string `json:"preferred_ike"`
-	PreferredEsp   string `json:"preferred_esp"`
-	ForcePreferred bool   `json:"force_preferred"`
-}
+type linkPostData = payload.LinkPost
 
 func linkPost(c *gin.Context) {
 	data := &linkPostData{}
@@ -50,19 +39,7 @@ func linkPost(c *gin.Context) {
 	req.Do(c)
 }
 
-type linkPutData struct {
-	Name           string `json:"name"`
-	Status         string `json:"status"`
-	Protocol       string `json:"protocol"`
-	WgPort         int    `json:"wg_port"`
-	Key            bool   `json:"key"`
-	Ipv6           bool   `json:"ipv6"`
-	HostCheck      bool   `json:"host_check"`
-	Action         string `json:"action"`
-	PreferredIke   string `json:"preferred_ike"`
-	PreferredEsp   string `json:"preferred_esp"`
-	ForcePreferred bool   `json:"force_preferred"`
-}
+type linkPutData = payload.LinkPut
 
 type linkStateHostData struct {
 	State   bool `json:"state"`
@@ -137,11 +114,7 @@ func linkLocationGet(c *gin.Context) {
 	req.Do(c)
 }
 
-type linkLocationPostData struct {
-	Name     string `json:"name"`
-	LinkId   string `json:"link_id"`
-	Location string `json:"location"`
-}
+type linkLocationPostData = payload.LinkLocationPost
 
 func linkLocationPost(c *gin.Context) {
 	linkId := utils.FilterStr(c.Params.ByName("link_id"), 128)
@@ -156,11 +129,7 @@ func linkLocationPost(c *gin.Context) {
 	req.Do(c)
 }
 
-type linkLocationPutData struct {
-	Name     string `json:"name"`
-	LinkId   string `json:"link_id"`
-	Location string `json:"location"`
-}
+type linkLocationPutData = payload.LinkLocationPut
 
 func linkLocationPut(c *gin.Context) {
 	linkId := utils.FilterStr(c.Params.ByName("link_id"), 128)
@@ -188,9 +157,7 @@ func linkLocationDelete(c *gin.Context) {
 	req.Do(c)
 }
 
-type linkLocationRoutePostData struct {
-	Network string `json:"network"`
-}
+type linkLocationRoutePostData = payload.LinkLocationRoutePost
 
 func linkLocationRoutePost(c *gin.Context) {
 	linkId := utils.FilterStr(c.Params.ByName("link_id"), 128)
@@ -206,9 +173,7 @@ func linkLocationRoutePost(c *gin.Context) {
 	req.Do(c)
 }
 
-type linkLocationRoutePutData struct {
-	Network string `json:"network"`
-}
+type linkLocationRoutePutData = payload.LinkLocationRoutePut
 
 func linkLocationRoutePut(c *gin.Context) {
 	linkId := utils.FilterStr(c.Params.ByName("link_id"), 128)
@@ -268,17 +233,7 @@ func linkLocationHostConfGet(c *gin.Context) {
 	req.Do(c)
 }
 
-type linkLocationHostPostData struct {
-	Name          string `json:"name"`
-	Timeout       int    `json:"timeout"`
-	Priority      int    `json:"priority"`
-	Backoff       int    `json:"backoff"`
-	Static        bool   `json:"static"`
-	PublicAddress string `json:"public_address"`
-	LocalAddress  string `json:"local_address"`
-	Address6      string `json:"address6"`
-	WgPublicKey   string `json:"wg_public_key"`
-}
+type linkLocationHostPostData = payload.LinkLocationHostPost
 
 func linkLocationHostPost(c *gin.Context) {
 	linkId := utils.FilterStr(c.Params.ByName("link_id"), 128)
@@ -294,17 +249,7 @@ func linkLocationHostPost(c *gin.Context) {
 	req.Do(c)
 }
 
-type linkLocationHostPutData struct {
-	Name          string `json:"name"`
-	Timeout       int    `json:"timeout"`
-	Priority      int    `json:"priority"`
-	Backoff       int    `json:"backoff"`
-	Static        bool   `json:"static"`
-	PublicAddress string `json:"public_address"`
-	LocalAddress  string `json:"local_address"`
-	Address6      string `json:"address6"`
-	WgPublicKey   string `json:"wg_public_key"`
-}
+type linkLocationHostPutData = payload.LinkLocationHostPut
 
 func linkLocationHostPut(c *gin.Context) {
 	linkId := utils.FilterStr(c.Params.ByName("link_id"), 128)
@@ -336,9 +281,7 @@ func linkLocationHostDelete(c *gin.Context) {
 	req.Do(c)
 }
 
-type linkLocationPeerPostData struct {
-	PeerId string `json:"peer_id"`
-}
+type linkLocationPeerPostData = payload.LinkLocationPeer
 
 func linkLocationPeerPost(c *gin.Context) {
 	linkId := utils.FilterStr(c.Params.ByName("link_id"), 128)
@@ -369,9 +312,7 @@ func linkLocationPeerDelete(c *gin.Context) {
 	req.Do(c)
 }
 
-type linkLocationTransitPostData struct {
-	TransitId string `json:"transit_id"`
-}
+type linkLocationTransitPostData = payload.LinkLocationTransit
 
 func linkLocationTransitPost(c *gin.Context) {
 	linkId := utils.FilterStr(c.Params.ByName("link_id"), 128)
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNonceCache(t *testing.T) {
+	cache := newNonceCache(2)
+
+	if cache.Add("a", time.Minute) != nonceAdded {
+		t.Fatal("nonce a not added")
+	}
+	if cache.Add("a", time.Minute) != nonceUsed {
+		t.Fatal("nonce a reused")
+	}
+	if cache.Add("b", time.Minute) != nonceAdded {
+		t.Fatal("nonce b not added")
+	}
+	if cache.Add("c", time.Minute) != nonceFull {
+		t.Fatal("live nonce evicted")
+	}
+	if cache.Add("a", time.Minute) != nonceUsed {
+		t.Fatal("nonce a forgotten while cache full")
+	}
+
+	time.Sleep(2 * time.Millisecond)
+
+	if cache.Add("c", time.Millisecond) != nonceAdded {
+		t.Fatal("expired nonce not evicted")
+	}
+	if _, ok := cache.entries["a"]; ok {
+		t.Fatal("evicted nonce still cached")
+	}
+	if cache.Add("a", time.Millisecond) != nonceAdded {
+		t.Fatal("expired nonce a not accepted")
+	}
+	if _, ok := cache.entries["c"]; !ok {
+		t.Fatal("live nonce c removed")
+	}
+}
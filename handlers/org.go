@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-web/payload"
 	"github.com/pritunl/pritunl-web/request"
 	"github.com/pritunl/pritunl-web/utils"
 )
@@ -29,10 +30,7 @@ func orgGet(c *gin.Context) {
 	req.Do(c)
 }
 
-type orgPostData struct {
-	Name    string `json:"name"`
-	AuthApi bool   `json:"auth_api"`
-}
+type orgPostData = payload.OrgPost
 
 func orgPost(c *gin.Context) {
 	data := &orgPostData{}
@@ -46,12 +44,7 @@ func orgPost(c *gin.Context) {
 	req.Do(c)
 }
 
-type orgPutData struct {
-	Name       string `json:"name"`
-	AuthApi    bool   `json:"auth_api"`
-	AuthToken  bool   `json:"auth_token"`
-	AuthSecret bool   `json:"auth_secret"`
-}
+type orgPutData = payload.OrgPut
 
 func orgPut(c *gin.Context) {
 	orgId := utils.FilterStr(c.Params.ByName("org_id"), 128)
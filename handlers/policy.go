@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-web/policy"
+	"github.com/pritunl/pritunl-web/request"
+	"github.com/sirupsen/logrus"
+)
+
+func Policy(class policy.Class) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientIp := request.ClientIp(c)
+
+		if !policy.Check(class, clientIp) {
+			logrus.WithFields(logrus.Fields{
+				"class":     class,
+				"client_ip": clientIp,
+				"method":    c.Request.Method,
+				"path":      c.Request.URL.Path,
+			}).Warn("handlers: Request denied by network policy")
+
+			request.AbortWithStatus(c, 403, "Forbidden")
+			return
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package handlers_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pritunl/pritunl-web/constants"
+	"github.com/pritunl/pritunl-web/policy"
+	"github.com/pritunl/pritunl-web/utils"
+)
+
+func TestPolicyRemoteAddr(t *testing.T) {
+	h := newHarness(t)
+
+	deny, err := utils.ParseCidrs(clientIp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policy.Set(policy.AdminUi, &policy.Policy{
+		Deny: deny,
+	})
+	defer policy.Set(policy.AdminUi, nil)
+
+	constants.ReverseProxyHeader = "X-Forwarded-For"
+	defer func() {
+		constants.ReverseProxyHeader = ""
+	}()
+
+	for _, target := range []string{
+		"/login",
+		"/logo.png",
+		"/fredoka-one.woff",
+		"/ubuntu-bold.eot",
+	} {
+		h.backend.Reset()
+
+		req := httptest.NewRequest("GET", target, nil)
+		req.RemoteAddr = clientIp + ":43210"
+		req.Header.Set("X-Forwarded-For", "203.0.113.9")
+
+		resp := httptest.NewRecorder()
+		h.router.ServeHTTP(resp, req)
+
+		if resp.Code != 403 {
+			t.Errorf("%s status %d, expected 403", target, resp.Code)
+		}
+		if len(h.backend.Requests()) != 0 {
+			t.Errorf("%s reached backend", target)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-web/payload"
 	"github.com/pritunl/pritunl-web/request"
 	"github.com/pritunl/pritunl-web/utils"
 )
@@ -29,64 +30,7 @@ func serverGet(c *gin.Context) {
 	req.Do(c)
 }
 
-type serverPostPutData struct {
-	Name             string      `json:"name"`
-	Network          string      `json:"network"`
-	NetworkWg        string      `json:"network_wg"`
-	NetworkMode      string      `json:"network_mode"`
-	NetworkStart     string      `json:"network_start"`
-	NetworkEnd       string      `json:"network_end"`
-	RestrictRoutes   bool        `json:"restrict_routes"`
-	Wg               bool        `json:"wg"`
-	HideOvpn         bool        `json:"hide_ovpn"`
-	OvpnDco          bool        `json:"ovpn_dco"`
-	Ipv6             bool        `json:"ipv6"`
-	Ipv6Firewall     bool        `json:"ipv6_firewall"`
-	DynamicFirewall  bool        `json:"dynamic_firewall"`
-	BypassSsoAuth    bool        `json:"bypass_sso_auth"`
-	GeoSort          bool        `json:"geo_sort"`
-	ForceConnect     bool        `json:"force_connect"`
-	DeviceAuth       bool        `json:"device_auth"`
-	BindAddress      string      `json:"bind_address"`
-	Protocol         string      `json:"protocol"`
-	Port             int         `json:"port"`
-	PortWg           int         `json:"port_wg"`
-	DhParamBits      int         `json:"dh_param_bits"`
-	Groups           []string    `json:"groups"`
-	MultiDevice      bool        `json:"multi_device"`
-	DnsServers       []string    `json:"dns_servers"`
-	SearchDomain     string      `json:"search_domain"`
-	InterClient      bool        `json:"inter_client"`
-	PingInterval     int         `json:"ping_interval"`
-	PingTimeout      int         `json:"ping_timeout"`
-	PingIntervalWg   int         `json:"ping_interval_wg"`
-	PingTimeoutWg    int         `json:"ping_timeout_wg"`
-	LinkPingInterval int         `json:"link_ping_interval"`
-	LinkPingTimeout  int         `json:"link_ping_timeout"`
-	InactiveTimeout  int         `json:"inactive_timeout"`
-	SessionTimeout   int         `json:"session_timeout"`
-	AllowedDevices   string      `json:"allowed_devices"`
-	MaxClients       int         `json:"max_clients"`
-	MaxDevices       int         `json:"max_devices"`
-	ReplicaCount     int         `json:"replica_count"`
-	Vxlan            bool        `json:"vxlan"`
-	DnsMapping       bool        `json:"dns_mapping"`
-	RouteDns         bool        `json:"route_dns"`
-	Debug            bool        `json:"debug"`
-	SsoAuth          bool        `json:"sso_auth"`
-	OtpAuth          bool        `json:"otp_auth"`
-	LzoCompression   bool        `json:"lzo_compression"`
-	Cipher           string      `json:"cipher"`
-	Hash             string      `json:"hash"`
-	BlockOutsideDns  bool        `json:"block_outside_dns"`
-	JumboFrames      bool        `json:"jumbo_frames"`
-	PreConnectMsg    string      `json:"pre_connect_msg"`
-	Policy           string      `json:"policy"`
-	MssFix           interface{} `json:"mss_fix"`
-	TunMtu           int         `json:"tun_mtu"`
-	Fragment         int         `json:"fragment"`
-	Multihome        bool        `json:"multihome"`
-}
+type serverPostPutData = payload.Server
 
 func serverPost(c *gin.Context) {
 	data := &serverPostPutData{}
@@ -182,19 +126,7 @@ func serverRouteGet(c *gin.Context) {
 	req.Do(c)
 }
 
-type serverRoutePostPutData struct {
-	Network           string   `json:"network"`
-	Comment           string   `json:"comment"`
-	Metric            int      `json:"metric"`
-	Nat               bool     `json:"nat"`
-	NatInterface      string   `json:"nat_interface"`
-	NatNetmap         string   `json:"nat_netmap"`
-	Advertise         bool     `json:"advertise"`
-	AdvertiseResource []string `json:"advertise_resource"`
-	VpcRegion         string   `json:"vpc_region"`
-	VpcId             string   `json:"vpc_id"`
-	NetGateway        bool     `json:"net_gateway"`
-}
+type serverRoutePostPutData = payload.ServerRoute
 
 func serverRoutePost(c *gin.Context) {
 	serverId := utils.FilterStr(c.Params.ByName("server_id"), 128)
@@ -294,9 +226,7 @@ func serverLinkGet(c *gin.Context) {
 	req.Do(c)
 }
 
-type serverLinkPutData struct {
-	UseLocalAddress bool `json:"use_local_address"`
-}
+type serverLinkPutData = payload.ServerLink
 
 func serverLinkPut(c *gin.Context) {
 	serverId := utils.FilterStr(c.Params.ByName("server_id"), 128)
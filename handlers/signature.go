@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dropbox/godropbox/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-web/constants"
+	"github.com/pritunl/pritunl-web/errortypes"
+	"github.com/pritunl/pritunl-web/request"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	nonceAdded = iota
+	nonceUsed
+	nonceFull
+)
+
+type nonceEntry struct {
+	key       string
+	timestamp time.Time
+}
+
+type nonceCache struct {
+	lock    sync.Mutex
+	entries map[string]time.Time
+	ring    []nonceEntry
+	pos     int
+}
+
+func newNonceCache(size int) *nonceCache {
+	if size <= 0 {
+		size = 100000
+	}
+
+	return &nonceCache{
+		entries: map[string]time.Time{},
+		ring:    make([]nonceEntry, size),
+	}
+}
+
+func (n *nonceCache) Add(key string, window time.Duration) int {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	now := time.Now()
+	if timestamp, ok := n.entries[key]; ok && now.Sub(timestamp) < window {
+		return nonceUsed
+	}
+
+	evict := n.ring[n.pos]
+	if evict.key != "" {
+		if now.Sub(evict.timestamp) < window {
+			return nonceFull
+		}
+
+		if n.entries[evict.key].Equal(evict.timestamp) {
+			delete(n.entries, evict.key)
+		}
+	}
+
+	n.ring[n.pos] = nonceEntry{
+		key:       key,
+		timestamp: now,
+	}
+	n.entries[key] = now
+	n.pos = (n.pos + 1) % len(n.ring)
+
+	return nonceAdded
+}
+
+var (
+	nonces           *nonceCache
+	unverifiedNonces *nonceCache
+	apiSecrets       = map[string]string{}
+	apiSecretsLock   = sync.RWMutex{}
+)
+
+func LoadApiSecrets() (err error) {
+	if constants.Api.SecretsPath == "" {
+		return
+	}
+
+	data, err := os.ReadFile(constants.Api.SecretsPath)
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "handlers: Failed to read API secrets"),
+		}
+		return
+	}
+
+	secrets := map[string]string{}
+	err = json.Unmarshal(data, &secrets)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrap(err, "handlers: Failed to parse API secrets"),
+		}
+		return
+	}
+
+	apiSecretsLock.Lock()
+	apiSecrets = secrets
+	apiSecretsLock.Unlock()
+
+	return
+}
+
+func signatureDeny(c *gin.Context, reason string) {
+	logrus.WithFields(logrus.Fields{
+		"client_ip": request.ClientIp(c),
+		"method":    c.Request.Method,
+		"path":      c.Request.URL.Path,
+		"reason":    reason,
+	}).Warn("handlers: API signature rejected")
+
+	request.AbortWithStatus(c, 401, reason)
+}
+
+func checkNonce(c *gin.Context, cache *nonceCache, key string,
+	window time.Duration) {
+
+	switch cache.Add(key, window) {
+	case nonceUsed:
+		signatureDeny(c, "Authentication nonce already used")
+	case nonceFull:
+		logrus.WithFields(logrus.Fields{
+			"client_ip": request.ClientIp(c),
+			"method":    c.Request.Method,
+			"path":      c.Request.URL.Path,
+		}).Error("handlers: API nonce cache full")
+
+		request.AbortWithStatus(c, 503, "Authentication nonce cache full")
+	}
+}
+
+func signature(secret, token, timestamp, nonce, method,
+	path string) string {
+
+	hash := hmac.New(sha256.New, []byte(secret))
+	hash.Write([]byte(strings.Join([]string{
+		token,
+		timestamp,
+		nonce,
+		strings.ToUpper(method),
+		path,
+	}, "&")))
+
+	return base64.StdEncoding.EncodeToString(hash.Sum(nil))
+}
+
+func Signature(c *gin.Context) {
+	authToken := c.Request.Header.Get("Auth-Token")
+	if authToken == "" {
+		return
+	}
+
+	authTimestamp := c.Request.Header.Get("Auth-Timestamp")
+	authNonce := c.Request.Header.Get("Auth-Nonce")
+	authSignature := c.Request.Header.Get("Auth-Signature")
+
+	if authTimestamp == "" || authNonce == "" || authSignature == "" {
+		signatureDeny(c, "Missing authentication headers")
+		return
+	}
+
+	if len(authToken) > 256 || len(authNonce) > 256 {
+		signatureDeny(c, "Authentication header length invalid")
+		return
+	}
+
+	timestamp, err := strconv.ParseInt(authTimestamp, 10, 64)
+	if err != nil {
+		signatureDeny(c, "Authentication timestamp invalid")
+		return
+	}
+
+	window := time.Duration(constants.Api.AuthWindow) * time.Second
+	skew := time.Since(time.Unix(timestamp, 0))
+	if skew > window || skew < -window {
+		signatureDeny(c, "Authentication timestamp outside window")
+		return
+	}
+
+	apiSecretsLock.RLock()
+	secret := apiSecrets[authToken]
+	apiSecretsLock.RUnlock()
+
+	if secret == "" {
+		checkNonce(c, unverifiedNonces, authToken+"&"+authNonce, 2*window)
+		return
+	}
+
+	expected := signature(secret, authToken, authTimestamp, authNonce,
+		c.Request.Method, c.Request.URL.Path)
+
+	if !hmac.Equal([]byte(authSignature), []byte(expected)) {
+		signatureDeny(c, "Authentication signature invalid")
+		return
+	}
+
+	checkNonce(c, nonces, authToken+"&"+authNonce, 2*window)
+}
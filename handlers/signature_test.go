@@ -0,0 +1,150 @@
+package handlers_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-web/constants"
+	"github.com/pritunl/pritunl-web/handlers"
+)
+
+func (h *harness) DoSigned(token, secret, nonce string) (
+	resp *httptest.ResponseRecorder) {
+
+	h.backend.Reset()
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	hash := hmac.New(sha256.New, []byte(secret))
+	hash.Write([]byte(strings.Join([]string{
+		token, timestamp, nonce, "GET", "/settings",
+	}, "&")))
+
+	req := httptest.NewRequest("GET", "/settings", nil)
+	req.RemoteAddr = clientIp + ":43210"
+	req.Header.Set("Auth-Token", token)
+	req.Header.Set("Auth-Timestamp", timestamp)
+	req.Header.Set("Auth-Nonce", nonce)
+	req.Header.Set("Auth-Signature",
+		base64.StdEncoding.EncodeToString(hash.Sum(nil)))
+
+	resp = httptest.NewRecorder()
+	h.router.ServeHTTP(resp, req)
+
+	return
+}
+
+func TestSignatureNonce(t *testing.T) {
+	secretsPath := filepath.Join(t.TempDir(), "secrets.json")
+	err := os.WriteFile(secretsPath,
+		[]byte(`{"known": "known-secret"}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	constants.Api.SecretsPath = secretsPath
+	defer func() {
+		constants.Api.SecretsPath = ""
+		handlers.LoadApiSecrets()
+	}()
+
+	err = handlers.LoadApiSecrets()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := newHarness(t)
+	constants.WebStrict = false
+	defer func() {
+		constants.WebStrict = true
+	}()
+
+	resp := h.DoSigned("known", "wrong-secret", "nonce-1")
+	if resp.Code != 401 {
+		t.Fatalf("invalid signature status %d, expected 401", resp.Code)
+	}
+
+	resp = h.DoSigned("known", "known-secret", "nonce-1")
+	if resp.Code != 200 {
+		t.Fatalf("signed status %d, expected 200", resp.Code)
+	}
+
+	resp = h.DoSigned("known", "known-secret", "nonce-1")
+	if resp.Code != 401 {
+		t.Fatalf("replayed status %d, expected 401", resp.Code)
+	}
+
+	resp = h.DoSigned("unknown", "any-secret", "nonce-2")
+	if resp.Code != 200 {
+		t.Fatalf("unverified status %d, expected 200", resp.Code)
+	}
+
+	resp = h.DoSigned("unknown", "any-secret", "nonce-2")
+	if resp.Code != 401 {
+		t.Fatalf("unverified replay status %d, expected 401", resp.Code)
+	}
+
+	resp = h.DoSigned("known", "known-secret", "nonce-2")
+	if resp.Code != 200 {
+		t.Fatalf("signed status %d, expected 200", resp.Code)
+	}
+}
+
+func TestSignatureNonceFlood(t *testing.T) {
+	secretsPath := filepath.Join(t.TempDir(), "secrets.json")
+	err := os.WriteFile(secretsPath,
+		[]byte(`{"known": "known-secret"}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	constants.Api.SecretsPath = secretsPath
+	defer func() {
+		constants.Api.SecretsPath = ""
+		handlers.LoadApiSecrets()
+	}()
+
+	err = handlers.LoadApiSecrets()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := newHarness(t)
+	constants.Api.NonceCacheSize = 2
+	constants.WebStrict = false
+	defer func() {
+		constants.Api.NonceCacheSize = 1000
+		constants.WebStrict = true
+	}()
+
+	router := gin.New()
+	handlers.Register(router)
+	h.router = router
+
+	for i := 0; i < 2; i++ {
+		resp := h.DoSigned("unknown", "any-secret",
+			"flood-"+strconv.Itoa(i))
+		if resp.Code != 200 {
+			t.Fatalf("unverified status %d, expected 200", resp.Code)
+		}
+	}
+
+	resp := h.DoSigned("unknown", "any-secret", "flood-2")
+	if resp.Code != 503 {
+		t.Fatalf("unverified flood status %d, expected 503", resp.Code)
+	}
+
+	resp = h.DoSigned("known", "known-secret", "flood-2")
+	if resp.Code != 200 {
+		t.Fatalf("signed status %d, expected 200", resp.Code)
+	}
+}
@@ -4,13 +4,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/pritunl/pritunl-web/request"
 	"path"
-	"strings"
 )
 
 func staticPathGet(c *gin.Context) {
 	pth := c.Params.ByName("path")
-	pth = strings.Replace(pth, "..", "", -1)
-	pth = path.Clean(pth)
+	pth = path.Clean("/" + pth)
 
 	req := &request.Request{
 		Method: "GET",
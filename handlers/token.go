@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dropbox/godropbox/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-web/constants"
+	"github.com/pritunl/pritunl-web/errortypes"
+	"github.com/pritunl/pritunl-web/request"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+func sealToken(token *Token) (tokenStr string, err error) {
+	data, err := json.Marshal(token)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrap(err, "handlers: Failed to marshal token"),
+		}
+		return
+	}
+
+	var nonce [24]byte
+	_, err = rand.Read(nonce[:])
+	if err != nil {
+		err = &errortypes.UnknownError{
+			errors.Wrap(err, "handlers: Failed to generate token nonce"),
+		}
+		return
+	}
+
+	tokenByt := secretbox.Seal(nonce[:], data, &nonce,
+		constants.WebSecret.Load())
+	tokenStr = base64.URLEncoding.EncodeToString(tokenByt)
+
+	return
+}
+
+func openToken(tokenStr string) (token *Token, reason string) {
+	tokenByt, err := base64.URLEncoding.DecodeString(tokenStr)
+	if err != nil {
+		reason = "Failed to decode token"
+		return
+	}
+
+	if len(tokenByt) < 28 {
+		reason = "Token length invalid"
+		return
+	}
+
+	var nonce [24]byte
+	copy(nonce[:], tokenByt[:24])
+	encByt := tokenByt[24:]
+
+	decByt, ok := secretbox.Open(nil, encByt, &nonce,
+		constants.WebSecret.Load())
+	if !ok {
+		reason = "Failed to decrypt token"
+		return
+	}
+
+	token = &Token{}
+
+	err = json.Unmarshal(decByt, token)
+	if err != nil {
+		reason = "Failed to unmarshal token"
+		return
+	}
+
+	if token.Id == "" {
+		reason = "Token id invalid"
+		return
+	}
+
+	return
+}
+
+func setToken(c *gin.Context, token *Token) (err error) {
+	tokenStr, err := sealToken(token)
+	if err != nil {
+		return
+	}
+
+	request.SetCookie(c.Writer, c.Request, &http.Cookie{
+		Name:     "token",
+		Value:    tokenStr,
+		Path:     "/",
+		HttpOnly: true,
+	})
+
+	return
+}
+
+func stampToken(token *Token) {
+	if constants.Session.MaxAge <= 0 || token.Expires != 0 {
+		return
+	}
+
+	token.Expires = time.Now().Add(time.Duration(
+		constants.Session.MaxAge) * time.Second).Unix()
+	if token.Ttl > token.Expires {
+		token.Ttl = token.Expires
+	}
+}
+
+func sessionResponse(c *gin.Context, resp *http.Response) (err error) {
+	if constants.WebSecret.Load() == nil {
+		return
+	}
+
+	vals := resp.Header.Values("Set-Cookie")
+	resp.Header.Del("Set-Cookie")
+
+	for _, val := range vals {
+		cookie, e := http.ParseSetCookie(val)
+		if e == nil && cookie.Name == "token" && cookie.Value != "" &&
+			cookie.MaxAge >= 0 {
+
+			token, reason := openToken(cookie.Value)
+			if reason == "" {
+				stampToken(token)
+				if bindingEnabled() {
+					bindToken(c, token)
+				}
+
+				cookie.Value, err = sealToken(token)
+				if err != nil {
+					return
+				}
+				val = cookie.String()
+			}
+		}
+
+		resp.Header.Add("Set-Cookie", val)
+	}
+
+	return
+}
+
+func NewSession(id string) (tokenStr, csrf string, err error) {
+	token := &Token{
+		Id: id,
+		Ttl: time.Now().Add(
+			time.Duration(constants.Session.Ttl) * time.Second).Unix(),
+	}
+	stampToken(token)
+
+	tokenStr, err = sealToken(token)
+	if err != nil {
+		return
+	}
+	csrf = csrfToken(token)
+
+	return
+}
+
+func bindingEnabled() bool {
+	return constants.Session.TokenBinding == "audit" ||
+		constants.Session.TokenBinding == "enforce"
+}
+
+func tokenHash(val string) string {
+	hash := hmac.New(sha256.New, constants.WebSecret.Load()[:])
+	hash.Write([]byte(val))
+	return base64.RawURLEncoding.EncodeToString(hash.Sum(nil)[:16])
+}
+
+func networkBinding(c *gin.Context) string {
+	ip := net.ParseIP(request.ClientIp(c))
+	if ip == nil {
+		return ""
+	}
+
+	var network *net.IPNet
+	if ip4 := ip.To4(); ip4 != nil {
+		network = &net.IPNet{
+			IP:   ip4.Mask(net.CIDRMask(24, 32)),
+			Mask: net.CIDRMask(24, 32),
+		}
+	} else {
+		network = &net.IPNet{
+			IP:   ip.Mask(net.CIDRMask(64, 128)),
+			Mask: net.CIDRMask(64, 128),
+		}
+	}
+
+	return tokenHash("network&" + network.String())
+}
+
+func agentFamily(userAgent string) string {
+	switch {
+	case userAgent == "":
+		return ""
+	case strings.Contains(userAgent, "Edg/"):
+		return "edge"
+	case strings.Contains(userAgent, "OPR/"):
+		return "opera"
+	case strings.Contains(userAgent, "Firefox/"):
+		return "firefox"
+	case strings.Contains(userAgent, "Chrome/"),
+		strings.Contains(userAgent, "Chromium/"):
+		return "chrome"
+	case strings.Contains(userAgent, "Safari/"):
+		return "safari"
+	}
+
+	family := strings.SplitN(userAgent, "/", 2)[0]
+	return strings.ToLower(strings.TrimSpace(family))
+}
+
+func agentBinding(c *gin.Context) string {
+	family := agentFamily(c.Request.UserAgent())
+	if family == "" {
+		return ""
+	}
+
+	return tokenHash("agent&" + family)
+}
+
+func certBinding(c *gin.Context) string {
+	cert := request.ClientCert(c.Request)
+	if cert == nil {
+		return ""
+	}
+
+	return request.CertFingerprint(cert)
+}
+
+func tokenBound(token *Token) bool {
+	return token.Network != "" || token.Agent != "" || token.Cert != ""
+}
+
+func bindToken(c *gin.Context, token *Token) {
+	token.Network = networkBinding(c)
+	token.Agent = agentBinding(c)
+	token.Cert = certBinding(c)
+}
+
+func checkBinding(c *gin.Context, token *Token) bool {
+	if !bindingEnabled() {
+		return true
+	}
+
+	if !tokenBound(token) {
+		if constants.Session.TokenBinding != "enforce" {
+			return true
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"client_ip": request.ClientIp(c),
+			"mode":      constants.Session.TokenBinding,
+		}).Warn("handlers: Token not bound")
+
+		return false
+	}
+
+	mismatch := []string{}
+	if token.Network != "" && token.Network != networkBinding(c) {
+		mismatch = append(mismatch, "network")
+	}
+	if token.Agent != "" && token.Agent != agentBinding(c) {
+		mismatch = append(mismatch, "agent")
+	}
+	if token.Cert != "" && token.Cert != certBinding(c) {
+		mismatch = append(mismatch, "cert")
+	}
+
+	if len(mismatch) == 0 {
+		return true
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"client_ip": request.ClientIp(c),
+		"mismatch":  strings.Join(mismatch, ","),
+		"mode":      constants.Session.TokenBinding,
+	}).Warn("handlers: Token binding mismatch")
+
+	return constants.Session.TokenBinding != "enforce"
+}
+
+func renewToken(c *gin.Context, token *Token) {
+	if constants.Session.Renew <= 0 || token.Expires == 0 {
+		return
+	}
+
+	now := time.Now()
+	lifetime := time.Duration(constants.Session.Ttl) * time.Second
+	renewed := *token
+
+	remaining := time.Unix(token.Ttl, 0).Sub(now)
+	threshold := time.Duration(
+		float64(lifetime) * (1 - constants.Session.Renew))
+
+	if remaining > threshold {
+		return
+	}
+
+	renewed.Ttl = now.Add(lifetime).Unix()
+	if renewed.Ttl > renewed.Expires {
+		renewed.Ttl = renewed.Expires
+	}
+	if renewed.Ttl <= token.Ttl {
+		return
+	}
+
+	err := setToken(c, &renewed)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("handlers: Failed to renew session token")
+		return
+	}
+
+	*token = renewed
+}
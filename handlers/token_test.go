@@ -0,0 +1,198 @@
+package handlers_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pritunl/pritunl-web/constants"
+	"github.com/pritunl/pritunl-web/handlers"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+func openRaw(t *testing.T, tokenStr string) (token *handlers.Token) {
+	tokenByt, err := base64.URLEncoding.DecodeString(tokenStr)
+	if err != nil || len(tokenByt) < 24 {
+		t.Fatalf("invalid token %q", tokenStr)
+	}
+
+	var nonce [24]byte
+	copy(nonce[:], tokenByt[:24])
+
+	data, ok := secretbox.Open(nil, tokenByt[24:], &nonce,
+		constants.WebSecret.Load())
+	if !ok {
+		t.Fatal("failed to open token")
+	}
+
+	token = &handlers.Token{}
+	err = json.Unmarshal(data, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return
+}
+
+func sealTokenRaw(t *testing.T, token *handlers.Token) string {
+	data, err := json.Marshal(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sealRaw(data)
+}
+
+func responseToken(t *testing.T,
+	resp *httptest.ResponseRecorder) *handlers.Token {
+
+	for _, cookie := range resp.Result().Cookies() {
+		if cookie.Name == "token" {
+			return openRaw(t, cookie.Value)
+		}
+	}
+	return nil
+}
+
+func (h *harness) DoToken(method, target, data, tokenStr string) (
+	resp *httptest.ResponseRecorder) {
+
+	h.token, tokenStr = tokenStr, h.token
+	resp = h.Do(method, target, data, true)
+	h.token = tokenStr
+
+	return
+}
+
+func TestSessionExpiresAtLogin(t *testing.T) {
+	h := newHarness(t)
+	constants.Session.MaxAge = 7200
+
+	ttl := time.Now().Add(3 * time.Hour).Unix()
+	h.backend.Header.Add("Set-Cookie", (&http.Cookie{
+		Name: "token",
+		Value: sealTokenRaw(t, &handlers.Token{
+			Id:  "login-session",
+			Ttl: ttl,
+		}),
+		Path:     "/",
+		HttpOnly: true,
+	}).String())
+
+	resp := h.Do("POST", "/auth/session", "{}", false)
+	if resp.Code != 200 {
+		t.Fatalf("login status %d, expected 200", resp.Code)
+	}
+
+	token := responseToken(t, resp)
+	if token == nil {
+		t.Fatal("login token cookie missing")
+	}
+
+	maxExpires := time.Now().Add(2 * time.Hour).Unix()
+	if token.Id != "login-session" || token.Expires == 0 ||
+		token.Expires > maxExpires || token.Ttl > token.Expires {
+
+		t.Fatalf("login token not stamped %+v", token)
+	}
+}
+
+func TestSessionRenew(t *testing.T) {
+	h := newHarness(t)
+	constants.Session.MaxAge = 7200
+	constants.Session.Renew = 0.5
+
+	now := time.Now()
+
+	resp := h.DoToken("GET", "/settings", "", sealTokenRaw(t,
+		&handlers.Token{
+			Id:  "legacy-session",
+			Ttl: now.Add(10 * time.Minute).Unix(),
+		}))
+	if resp.Code != 200 || responseToken(t, resp) != nil {
+		t.Fatal("token without expires renewed")
+	}
+
+	expires := now.Add(30 * time.Minute).Unix()
+	resp = h.DoToken("GET", "/settings", "", sealTokenRaw(t,
+		&handlers.Token{
+			Id:      "renew-session",
+			Ttl:     now.Add(10 * time.Minute).Unix(),
+			Expires: expires,
+		}))
+	if resp.Code != 200 {
+		t.Fatalf("renew status %d, expected 200", resp.Code)
+	}
+
+	token := responseToken(t, resp)
+	if token == nil || token.Ttl != expires || token.Expires != expires {
+		t.Fatalf("token not renewed up to expires %+v", token)
+	}
+}
+
+func TestTokenBinding(t *testing.T) {
+	h := newHarness(t)
+	constants.Session.TokenBinding = "enforce"
+	defer func() {
+		constants.Session.TokenBinding = ""
+	}()
+
+	h.backend.Header.Add("Set-Cookie", (&http.Cookie{
+		Name: "token",
+		Value: sealTokenRaw(t, &handlers.Token{
+			Id:  "bound-session",
+			Ttl: time.Now().Add(time.Hour).Unix(),
+		}),
+		Path: "/",
+	}).String())
+
+	resp := h.Do("POST", "/auth/session", "{}", false)
+	token := responseToken(t, resp)
+	if token == nil || token.Network == "" {
+		t.Fatalf("login token not bound %+v", token)
+	}
+	h.backend.Header.Del("Set-Cookie")
+
+	var bound string
+	for _, cookie := range resp.Result().Cookies() {
+		if cookie.Name == "token" {
+			bound = cookie.Value
+		}
+	}
+
+	resp = h.DoToken("GET", "/settings", "", bound)
+	if resp.Code != 200 {
+		t.Fatalf("bound token status %d, expected 200", resp.Code)
+	}
+
+	resp = h.Do("GET", "/settings", "", true)
+	if resp.Code != 401 {
+		t.Fatalf("unbound token status %d, expected 401", resp.Code)
+	}
+	for _, req := range h.backend.Requests() {
+		if req.Path == "/settings" {
+			t.Fatal("unbound token reached backend")
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/settings", nil)
+	req.RemoteAddr = "203.0.113.9:43210"
+	req.AddCookie(&http.Cookie{
+		Name:  "token",
+		Value: bound,
+	})
+	rec := httptest.NewRecorder()
+	h.router.ServeHTTP(rec, req)
+	if rec.Code != 401 {
+		t.Fatalf("token from other network status %d, expected 401",
+			rec.Code)
+	}
+
+	constants.Session.TokenBinding = "audit"
+	resp = h.Do("GET", "/settings", "", true)
+	if resp.Code != 200 || responseToken(t, resp) != nil {
+		t.Fatal("unbound token rejected or bound in audit mode")
+	}
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-web/payload"
 	"github.com/pritunl/pritunl-web/request"
 	"github.com/pritunl/pritunl-web/utils"
 )
@@ -52,28 +53,7 @@ func userGet(c *gin.Context) {
 	req.Do(c)
 }
 
-type userPortForwardingData struct {
-	Protocol string `json:"protocol"`
-	Port     string `json:"port"`
-	Dport    string `json:"dport"`
-}
-
-type userPostData struct {
-	Name            string                   `json:"name"`
-	Email           string                   `json:"email"`
-	AuthType        string                   `json:"auth_type"`
-	YubicoId        string                   `json:"yubico_id"`
-	Groups          []string                 `json:"groups"`
-	Pin             string                   `json:"pin"`
-	Disabled        bool                     `json:"disabled"`
-	NetworkLinks    []string                 `json:"network_links"`
-	BypassSecondary bool                     `json:"bypass_secondary"`
-	ClientToClient  bool                     `json:"client_to_client"`
-	MacAddresses    []string                 `json:"mac_addresses"`
-	DnsServers      []string                 `json:"dns_servers"`
-	DnsSuffix       string                   `json:"dns_suffix"`
-	PortForwarding  []userPortForwardingData `json:"port_forwarding"`
-}
+type userPostData = payload.UserPost
 
 func userPost(c *gin.Context) {
 	orgId := utils.FilterStr(c.Params.ByName("org_id"), 128)
@@ -101,23 +81,7 @@ func userMultiPost(c *gin.Context) {
 	req.Do(c)
 }
 
-type userPutData struct {
-	Name            string                   `json:"name"`
-	Email           string                   `json:"email"`
-	AuthType        string                   `json:"auth_type"`
-	YubicoId        string                   `json:"yubico_id"`
-	Groups          []string                 `json:"groups"`
-	Pin             interface{}              `json:"pin"`
-	Disabled        bool                     `json:"disabled"`
-	NetworkLinks    []string                 `json:"network_links"`
-	BypassSecondary bool                     `json:"bypass_secondary"`
-	ClientToClient  bool                     `json:"client_to_client"`
-	MacAddresses    []string                 `json:"mac_addresses"`
-	DnsServers      []string                 `json:"dns_servers"`
-	DnsSuffix       string                   `json:"dns_suffix"`
-	PortForwarding  []userPortForwardingData `json:"port_forwarding"`
-	SendKeyEmail    bool                     `json:"send_key_email"`
-}
+type userPutData = payload.UserPut
 
 func userPut(c *gin.Context) {
 	orgId := utils.FilterStr(c.Params.ByName("org_id"), 128)
@@ -175,10 +139,7 @@ func userAuditGet(c *gin.Context) {
 	req.Do(c)
 }
 
-type userDevicePutData struct {
-	Name   string `json:"name"`
-	RegKey string `json:"reg_key"`
-}
+type userDevicePutData = payload.UserDevice
 
 func userDevicePut(c *gin.Context) {
 	data := &userDevicePutData{}
@@ -0,0 +1,15 @@
+package handlers
+
+import (
+	"testing"
+)
+
+func TestSignatureVector(t *testing.T) {
+	sig := signature("test-secret", "test-token", "1700000000",
+		"0123456789abcdef0123456789abcdef", "get", "/server/srv1")
+
+	expected := "/MJa3NxnFBIFvGg4Y2g4GmV8It0+wFa+NLeQLxb59L8="
+	if sig != expected {
+		t.Fatalf("signature %s, expected %s", sig, expected)
+	}
+}
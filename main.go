@@ -1,94 +1,370 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
-	"encoding/base64"
-	"fmt"
-	"io"
+	"crypto/x509"
+	"flag"
+	"net"
 	"net/http"
-	"net/url"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/dropbox/godropbox/errors"
 	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-web/certificate"
+	"github.com/pritunl/pritunl-web/config"
 	"github.com/pritunl/pritunl-web/constants"
 	"github.com/pritunl/pritunl-web/errortypes"
 	"github.com/pritunl/pritunl-web/handlers"
+	"github.com/pritunl/pritunl-web/metrics"
+	"github.com/pritunl/pritunl-web/proxyproto"
 	"github.com/pritunl/pritunl-web/request"
+	"github.com/pritunl/pritunl-web/systemd"
+	"github.com/pritunl/pritunl-web/tlsprofile"
 	"github.com/sirupsen/logrus"
 )
 
-func ParseRemoteAddr(remoteAddr string) (addr string) {
-	addr = remoteAddr[:strings.LastIndex(remoteAddr, ":")]
-	addr = strings.Replace(addr, "[", "", 1)
-	addr = strings.Replace(addr, "]", "", 1)
+func listen(name, addr string) (listener net.Listener, err error) {
+	listener = systemd.Listener(name)
+	if listener != nil {
+		logrus.WithFields(logrus.Fields{
+			"name":    name,
+			"address": listener.Addr().String(),
+		}).Info("main: Using systemd socket")
+	} else {
+		listener, err = net.Listen("tcp", addr)
+		if err != nil {
+			err = &errortypes.RequestError{
+				errors.Wrap(err, "main: Failed to listen"),
+			}
+			return
+		}
+	}
+
+	if len(constants.Proxy.ProtocolTrusted) > 0 {
+		listener = &proxyproto.Listener{
+			Listener: listener,
+			Trusted:  constants.Proxy.ProtocolTrusted,
+		}
+	}
+
 	return
 }
 
-func copyHeader(dst, src http.Header) {
-	for k, vv := range src {
-		for _, v := range vv {
-			dst.Add(k, v)
+func configureClientAuth(config *tls.Config) (err error) {
+	caByt, err := os.ReadFile(constants.AdminClient.CaPath)
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrap(err, "main: Failed to read client CA"),
 		}
+		return
+	}
+
+	clientCas := x509.NewCertPool()
+	if !clientCas.AppendCertsFromPEM(caByt) {
+		err = &errortypes.ParseError{
+			errors.New("main: No certificates found in client CA"),
+		}
+		return
+	}
+
+	config.ClientCAs = clientCas
+
+	if len(constants.AdminClient.Hosts) == 0 {
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+		return
+	}
+
+	certConfig := config.Clone()
+	certConfig.ClientAuth = tls.VerifyClientCertIfGiven
+
+	config.GetConfigForClient = func(hello *tls.ClientHelloInfo) (
+		*tls.Config, error) {
+
+		for _, host := range constants.AdminClient.Hosts {
+			if strings.EqualFold(hello.ServerName, host) {
+				return certConfig, nil
+			}
+		}
+		return nil, nil
+	}
+
+	return
+}
+
+func reload(conf *config.Config) {
+	err := reloadSecrets(conf)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("main: Failed to reload secrets")
+		return
+	}
+
+	err = request.ReloadTls()
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("main: Failed to reload internal TLS")
+	}
+
+	err = certificate.Reload()
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("main: Failed to reload certificates")
+	}
+
+	err = handlers.LoadApiSecrets()
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("main: Failed to reload API secrets")
 	}
 }
 
+func watchSignals(conf *config.Config) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGHUP)
+
+	for range sigChan {
+		logrus.Info("main: Reloading configuration")
+		reload(conf)
+	}
+}
+
+func watchShutdown(server *http.Server, done chan bool) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	<-sigChan
+	logrus.Info("main: Shutting down")
+
+	err := systemd.Notify("STOPPING=1")
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("main: Failed to notify systemd")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	err = server.Shutdown(ctx)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("main: Server shutdown error")
+	}
+
+	close(done)
+}
+
 func main() {
-	constants.ReverseProxyHeader = os.Getenv("REVERSE_PROXY_HEADER")
-	constants.ReverseProxyProtoHeader = os.Getenv("REVERSE_PROXY_PROTO_HEADER")
-	constants.RedirectServer = os.Getenv("REDIRECT_SERVER")
-	constants.BindHost = os.Getenv("BIND_HOST")
-	constants.BindPort = os.Getenv("BIND_PORT")
-	constants.InternalHost = os.Getenv("INTERNAL_ADDRESS")
-	constants.SslCert = os.Getenv("SSL_CERT")
-	constants.SslKey = os.Getenv("SSL_KEY")
-	webStrictStr := os.Getenv("WEB_STRICT")
-	webSecretStr := os.Getenv("WEB_SECRET")
-	os.Unsetenv("REVERSE_PROXY_HEADER")
-	os.Unsetenv("REVERSE_PROXY_PROTO_HEADER")
-	os.Unsetenv("REDIRECT_SERVER")
-	os.Unsetenv("BIND_HOST")
-	os.Unsetenv("BIND_PORT")
-	os.Unsetenv("INTERNAL_ADDRESS")
-	os.Unsetenv("SSL_CERT")
-	os.Unsetenv("SSL_KEY")
-	os.Unsetenv("WEB_STRICT")
-	os.Unsetenv("WEB_SECRET")
-
-	constants.Ssl = constants.SslCert != "" && constants.SslKey != ""
-	if constants.Ssl {
-		constants.Scheme = "https"
-	} else {
-		constants.Scheme = "http"
+	configPath := flag.String("config", "", "Path to configuration file")
+	flag.Parse()
+
+	switch flag.Arg(0) {
+	case "":
+	case "check":
+		os.Exit(checkCommand(*configPath, flag.Args()[1:]))
+	case "replay":
+		os.Exit(replayCommand(*configPath, flag.Args()[1:]))
+	default:
+		logrus.WithFields(logrus.Fields{
+			"command": flag.Arg(0),
+		}).Error("main: Unknown command")
+		os.Exit(2)
 	}
 
-	if webStrictStr == "false" {
-		constants.WebStrict = false
-	} else {
-		constants.WebStrict = true
+	conf, err := config.Load(*configPath)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("main: Failed to load configuration")
+		panic(err)
 	}
 
-	var err error
-	if webSecretStr != "" {
-		webSecretByt, e := base64.StdEncoding.DecodeString(webSecretStr)
-		if e != nil {
-			err = &errortypes.ParseError{
-				errors.Wrap(e, "main: Failed to decode web secret"),
-			}
+	err = applyConfig(conf)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("main: Invalid configuration")
+		panic(err)
+	}
 
+	err = handlers.LoadApiSecrets()
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("main: Failed to load API secrets")
+		panic(err)
+	}
+
+	err = systemd.Init()
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("main: Failed to load systemd sockets")
+		panic(err)
+	}
+
+	err = request.Init()
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("main: Failed to initialize internal client")
+		panic(err)
+	}
+
+	tlsProfile, err := tlsprofile.Build(
+		constants.Tls.Profile,
+		constants.Tls.MinVersion,
+		constants.Tls.MaxVersion,
+		constants.Tls.CipherSuites,
+		constants.Tls.Curves,
+		constants.Tls.Alpn,
+	)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("main: Invalid TLS configuration")
+		panic(err)
+	}
+
+	err = certificate.Init()
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("main: Failed to initialize certificates")
+		panic(err)
+	}
+
+	if !selfTest() {
+		err = &errortypes.ParseError{
+			errors.New("main: Startup self test failed"),
+		}
+		logrus.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("main: Invalid configuration")
+		panic(err)
+	}
+
+	if certificate.AcmeEnabled() && (!constants.RedirectServer ||
+		constants.BindPort == "80") {
+
+		logrus.Warn("main: Redirect server disabled, ACME HTTP-01 " +
+			"challenges unavailable")
+	}
+
+	go watchSignals(conf)
+
+	var metricsListener net.Listener
+	if constants.MetricsAddress != "" {
+		metricsListener, err = net.Listen("tcp", constants.MetricsAddress)
+		if err != nil {
 			logrus.WithFields(logrus.Fields{
 				"error": err,
-			}).Error("main: Failed to decode web secret")
+			}).Error("main: Metrics server listen error")
+			metricsListener = nil
+		}
+	}
+
+	var redirectListener net.Listener
+	if (constants.RedirectServer && constants.BindPort != "80") ||
+		systemd.HasListener("redirect") {
 
-			panic(err)
+		redirectListener, err = listen("redirect", constants.BindHost+":80")
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("main: Redirect server listen error")
+			redirectListener = nil
 		}
-		constants.WebSecret = &[32]byte{}
-		copy(constants.WebSecret[:], webSecretByt)
 	}
 
-	if constants.RedirectServer == "true" && constants.BindPort != "80" {
+	listener, err := listen("main",
+		constants.BindHost+":"+constants.BindPort)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("main: Server listen error")
+		panic(err)
+	}
+
+	gin.SetMode(gin.ReleaseMode)
+
+	router := gin.New()
+	handlers.Register(router)
+
+	server := &http.Server{
+		Addr:              constants.BindHost + ":" + constants.BindPort,
+		Handler:           router,
+		ReadTimeout:       2 * time.Minute,
+		ReadHeaderTimeout: 30 * time.Second,
+		WriteTimeout:      2 * time.Minute,
+		IdleTimeout:       1 * time.Minute,
+		MaxHeaderBytes:    500000,
+	}
+
+	if constants.Ssl {
+		server.TLSConfig = &tls.Config{
+			GetCertificate: certificate.GetCertificate,
+		}
+		tlsProfile.Apply(server.TLSConfig)
+		server.TLSConfig.NextProtos = certificate.NextProtos(
+			tlsProfile.NextProtos)
+		tlsProfile.Log()
+
+		if constants.AdminClient.CaPath != "" {
+			err = configureClientAuth(server.TLSConfig)
+			if err != nil {
+				logrus.WithFields(logrus.Fields{
+					"error": err,
+				}).Error("main: Client certificate authority load error")
+				panic(err)
+			}
+		}
+	}
+
+	err = dropPrivileges(conf, *configPath)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("main: Failed to drop privileges")
+		panic(err)
+	}
+
+	if metricsListener != nil {
+		go func() {
+			logrus.WithFields(logrus.Fields{
+				"address": constants.MetricsAddress,
+			}).Info("main: Starting metrics server")
+
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", metrics.Handler())
+
+			server := &http.Server{
+				Addr:         constants.MetricsAddress,
+				ReadTimeout:  1 * time.Minute,
+				WriteTimeout: 1 * time.Minute,
+				Handler:      mux,
+			}
+
+			err := server.Serve(metricsListener)
+			if err != nil {
+				logrus.WithFields(logrus.Fields{
+					"error": err,
+				}).Error("main: Metrics server error")
+			}
+		}()
+	}
+
+	if redirectListener != nil {
 		go func() {
 			logrus.WithFields(logrus.Fields{
 				"port": 80,
@@ -113,37 +389,15 @@ func main() {
 					if strings.HasPrefix(req.URL.Path,
 						"/.well-known/acme-challenge/") {
 
-						pathSplit := strings.Split(req.URL.Path, "/")
-						token := pathSplit[len(pathSplit)-1]
-
-						acmeUrl := url.URL{
-							Scheme: "http",
-							Host:   constants.InternalHost,
-							Path:   "/.well-known/acme-challenge/" + token,
-						}
-
-						resp, err := http.Get(acmeUrl.String())
-						if err != nil {
-							http.Error(
-								w,
-								fmt.Sprintf(
-									"%d %s",
-									http.StatusInternalServerError,
-									http.StatusText(
-										http.StatusInternalServerError,
-									),
-								),
-								http.StatusInternalServerError,
-							)
+						if certificate.AcmeEnabled() {
+							certificate.AcmeHandler().ServeHTTP(w, req)
 							return
 						}
-						defer resp.Body.Close()
 
-						copyHeader(w.Header(), resp.Header)
-						w.Header().Del("Server")
-						w.WriteHeader(resp.StatusCode)
-						io.Copy(w, resp.Body)
+						pathSplit := strings.Split(req.URL.Path, "/")
+						token := pathSplit[len(pathSplit)-1]
 
+						request.DoAcmeChallenge(w, req, token)
 						return
 					} else if strings.HasPrefix(req.URL.Path, "/check") ||
 						strings.HasPrefix(req.URL.Path, "/ping") {
@@ -170,7 +424,7 @@ func main() {
 				}),
 			}
 
-			err := server.ListenAndServe()
+			err := server.Serve(redirectListener)
 			if err != nil {
 				logrus.WithFields(logrus.Fields{
 					"error": err,
@@ -179,76 +433,36 @@ func main() {
 		}()
 	}
 
-	gin.SetMode(gin.ReleaseMode)
+	shutdownDone := make(chan bool)
+	go watchShutdown(server, shutdownDone)
 
-	router := gin.New()
-	handlers.Register(router)
-
-	server := &http.Server{
-		Addr:              constants.BindHost + ":" + constants.BindPort,
-		Handler:           router,
-		ReadTimeout:       2 * time.Minute,
-		ReadHeaderTimeout: 30 * time.Second,
-		WriteTimeout:      2 * time.Minute,
-		IdleTimeout:       1 * time.Minute,
-		MaxHeaderBytes:    500000,
+	err = systemd.Notify("READY=1")
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("main: Failed to notify systemd")
 	}
 
+	go systemd.Watchdog(func() error {
+		return request.Check(systemd.WatchdogInterval() / 4)
+	})
+
 	if constants.Ssl {
 		logrus.WithFields(logrus.Fields{
 			"port": constants.BindPort,
 		}).Info("main: Starting HTTPS server")
 
-		sslCertByt, e := base64.StdEncoding.DecodeString(constants.SslCert)
-		if e != nil {
-			logrus.WithFields(logrus.Fields{
-				"error": e,
-			}).Error("main: Server cert decode error")
-			panic(e)
-		}
-
-		sslKeyByt, e := base64.StdEncoding.DecodeString(constants.SslKey)
-		if e != nil {
-			logrus.WithFields(logrus.Fields{
-				"error": e,
-			}).Error("main: Server key decode error")
-			panic(e)
-		}
-
-		tlsCert, e := tls.X509KeyPair(sslCertByt, sslKeyByt)
-		if e != nil {
-			logrus.WithFields(logrus.Fields{
-				"error": e,
-			}).Error("main: Server tls decode error")
-			panic(e)
-		}
-
-		server.TLSConfig = &tls.Config{
-			MinVersion: tls.VersionTLS12,
-			MaxVersion: tls.VersionTLS13,
-			CipherSuites: []uint16{
-				tls.TLS_AES_128_GCM_SHA256,                        // 0x1301
-				tls.TLS_AES_256_GCM_SHA384,                        // 0x1302
-				tls.TLS_CHACHA20_POLY1305_SHA256,                  // 0x1303
-				tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,       // 0xc02b
-				tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,         // 0xc02f
-				tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,       // 0xc02c
-				tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,         // 0xc030
-				tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256, // 0xcca9
-				tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,   // 0xcca8
-			},
-			Certificates: []tls.Certificate{
-				tlsCert,
-			},
-		}
-
-		err = server.ListenAndServeTLS("", "")
+		err = server.ServeTLS(listener, "", "")
 	} else {
 		logrus.WithFields(logrus.Fields{
 			"port": constants.BindPort,
 		}).Info("main: Starting HTTP server")
 
-		err = server.ListenAndServe()
+		err = server.Serve(listener)
+	}
+	if err == http.ErrServerClosed {
+		<-shutdownDone
+		return
 	}
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type metric struct {
+	name   string
+	labels string
+	value  float64
+}
+
+var (
+	values     = map[string]*metric{}
+	helps      = map[string]string{}
+	valuesLock = sync.Mutex{}
+)
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", key, labels[key]))
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func get(name string, labels map[string]string) *metric {
+	lbls := formatLabels(labels)
+	key := name + lbls
+
+	m := values[key]
+	if m == nil {
+		m = &metric{
+			name:   name,
+			labels: lbls,
+		}
+		values[key] = m
+	}
+
+	return m
+}
+
+func Help(name, help string) {
+	valuesLock.Lock()
+	helps[name] = help
+	valuesLock.Unlock()
+}
+
+func Set(name string, labels map[string]string, value float64) {
+	valuesLock.Lock()
+	get(name, labels).value = value
+	valuesLock.Unlock()
+}
+
+func Add(name string, labels map[string]string, value float64) {
+	valuesLock.Lock()
+	get(name, labels).value += value
+	valuesLock.Unlock()
+}
+
+func Delete(name string, labels map[string]string) {
+	valuesLock.Lock()
+	delete(values, name+formatLabels(labels))
+	valuesLock.Unlock()
+}
+
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		valuesLock.Lock()
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		output := &strings.Builder{}
+		lastName := ""
+		for _, key := range keys {
+			m := values[key]
+			if m.name != lastName {
+				if help, ok := helps[m.name]; ok {
+					fmt.Fprintf(output, "# HELP %s %s\n", m.name, help)
+				}
+				lastName = m.name
+			}
+			fmt.Fprintf(output, "%s%s %g\n", m.name, m.labels, m.value)
+		}
+		valuesLock.Unlock()
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write([]byte(output.String()))
+	})
+}
@@ -0,0 +1,24 @@
+package payload
+
+type AdminPut struct {
+	Username  string `json:"username"`
+	Password  string `json:"password"`
+	YubikeyId string `json:"yubikey_id"`
+	SuperUser bool   `json:"super_user"`
+	AuthApi   bool   `json:"auth_api"`
+	Token     string `json:"token"`
+	Secret    string `json:"secret"`
+	Disabled  bool   `json:"disabled"`
+	OtpAuth   bool   `json:"otp_auth"`
+	OtpSecret bool   `json:"otp_secret"`
+}
+
+type AdminPost struct {
+	Username  string `json:"username"`
+	Password  string `json:"password"`
+	YubikeyId string `json:"yubikey_id"`
+	OtpAuth   bool   `json:"otp_auth"`
+	AuthApi   bool   `json:"auth_api"`
+	Disabled  bool   `json:"disabled"`
+	SuperUser bool   `json:"super_user"`
+}
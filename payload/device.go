@@ -0,0 +1,6 @@
+package payload
+
+type DeviceRegister struct {
+	Name   string `json:"name"`
+	RegKey string `json:"reg_key"`
+}
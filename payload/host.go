@@ -0,0 +1,17 @@
+package payload
+
+type Host struct {
+	Name              string `json:"name"`
+	PublicAddress     string `json:"public_address"`
+	PublicAddress6    string `json:"public_address6"`
+	RoutedSubnet6     string `json:"routed_subnet6"`
+	RoutedSubnet6Wg   string `json:"routed_subnet6_wg"`
+	ProxyNdp          bool   `json:"proxy_ndp"`
+	LocalAddress      string `json:"local_address"`
+	LocalAddress6     string `json:"local_address6"`
+	LinkAddress       string `json:"link_address"`
+	SyncAddress       string `json:"sync_address"`
+	AvailabilityGroup string `json:"availability_group"`
+	Priority          int    `json:"priority"`
+	InstanceId        string `json:"instance_id"`
+}
@@ -0,0 +1,81 @@
+package payload
+
+type LinkPost struct {
+	Name           string `json:"name"`
+	Type           string `json:"type"`
+	Status         string `json:"status"`
+	Protocol       string `json:"protocol"`
+	WgPort         int    `json:"wg_port"`
+	Ipv6           bool   `json:"ipv6"`
+	HostCheck      bool   `json:"host_check"`
+	Action         string `json:"action"`
+	PreferredIke   string `json:"preferred_ike"`
+	PreferredEsp   string `json:"preferred_esp"`
+	ForcePreferred bool   `json:"force_preferred"`
+}
+
+type LinkPut struct {
+	Name           string `json:"name"`
+	Status         string `json:"status"`
+	Protocol       string `json:"protocol"`
+	WgPort         int    `json:"wg_port"`
+	Key            bool   `json:"key"`
+	Ipv6           bool   `json:"ipv6"`
+	HostCheck      bool   `json:"host_check"`
+	Action         string `json:"action"`
+	PreferredIke   string `json:"preferred_ike"`
+	PreferredEsp   string `json:"preferred_esp"`
+	ForcePreferred bool   `json:"force_preferred"`
+}
+
+type LinkLocationPost struct {
+	Name     string `json:"name"`
+	LinkId   string `json:"link_id"`
+	Location string `json:"location"`
+}
+
+type LinkLocationPut struct {
+	Name     string `json:"name"`
+	LinkId   string `json:"link_id"`
+	Location string `json:"location"`
+}
+
+type LinkLocationRoutePost struct {
+	Network string `json:"network"`
+}
+
+type LinkLocationRoutePut struct {
+	Network string `json:"network"`
+}
+
+type LinkLocationHostPost struct {
+	Name          string `json:"name"`
+	Timeout       int    `json:"timeout"`
+	Priority      int    `json:"priority"`
+	Backoff       int    `json:"backoff"`
+	Static        bool   `json:"static"`
+	PublicAddress string `json:"public_address"`
+	LocalAddress  string `json:"local_address"`
+	Address6      string `json:"address6"`
+	WgPublicKey   string `json:"wg_public_key"`
+}
+
+type LinkLocationHostPut struct {
+	Name          string `json:"name"`
+	Timeout       int    `json:"timeout"`
+	Priority      int    `json:"priority"`
+	Backoff       int    `json:"backoff"`
+	Static        bool   `json:"static"`
+	PublicAddress string `json:"public_address"`
+	LocalAddress  string `json:"local_address"`
+	Address6      string `json:"address6"`
+	WgPublicKey   string `json:"wg_public_key"`
+}
+
+type LinkLocationPeer struct {
+	PeerId string `json:"peer_id"`
+}
+
+type LinkLocationTransit struct {
+	TransitId string `json:"transit_id"`
+}
@@ -0,0 +1,13 @@
+package payload
+
+type OrgPost struct {
+	Name    string `json:"name"`
+	AuthApi bool   `json:"auth_api"`
+}
+
+type OrgPut struct {
+	Name       string `json:"name"`
+	AuthApi    bool   `json:"auth_api"`
+	AuthToken  bool   `json:"auth_token"`
+	AuthSecret bool   `json:"auth_secret"`
+}
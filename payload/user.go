@@ -0,0 +1,47 @@
+package payload
+
+type UserPortForwarding struct {
+	Protocol string `json:"protocol"`
+	Port     string `json:"port"`
+	Dport    string `json:"dport"`
+}
+
+type UserPost struct {
+	Name            string               `json:"name"`
+	Email           string               `json:"email"`
+	AuthType        string               `json:"auth_type"`
+	YubicoId        string               `json:"yubico_id"`
+	Groups          []string             `json:"groups"`
+	Pin             string               `json:"pin"`
+	Disabled        bool                 `json:"disabled"`
+	NetworkLinks    []string             `json:"network_links"`
+	BypassSecondary bool                 `json:"bypass_secondary"`
+	ClientToClient  bool                 `json:"client_to_client"`
+	MacAddresses    []string             `json:"mac_addresses"`
+	DnsServers      []string             `json:"dns_servers"`
+	DnsSuffix       string               `json:"dns_suffix"`
+	PortForwarding  []UserPortForwarding `json:"port_forwarding"`
+}
+
+type UserPut struct {
+	Name            string               `json:"name"`
+	Email           string               `json:"email"`
+	AuthType        string               `json:"auth_type"`
+	YubicoId        string               `json:"yubico_id"`
+	Groups          []string             `json:"groups"`
+	Pin             interface{}          `json:"pin"`
+	Disabled        bool                 `json:"disabled"`
+	NetworkLinks    []string             `json:"network_links"`
+	BypassSecondary bool                 `json:"bypass_secondary"`
+	ClientToClient  bool                 `json:"client_to_client"`
+	MacAddresses    []string             `json:"mac_addresses"`
+	DnsServers      []string             `json:"dns_servers"`
+	DnsSuffix       string               `json:"dns_suffix"`
+	PortForwarding  []UserPortForwarding `json:"port_forwarding"`
+	SendKeyEmail    bool                 `json:"send_key_email"`
+}
+
+type UserDevice struct {
+	Name   string `json:"name"`
+	RegKey string `json:"reg_key"`
+}
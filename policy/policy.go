@@ -0,0 +1,82 @@
+package policy
+
+import (
+	"net"
+	"sync"
+
+	"github.com/pritunl/pritunl-web/utils"
+)
+
+type Class string
+
+const (
+	AdminUi   Class = "admin_ui"
+	AdminApi  Class = "admin_api"
+	Key       Class = "key"
+	LinkState Class = "link_state"
+	Setup     Class = "setup"
+)
+
+var Classes = []Class{
+	AdminUi,
+	AdminApi,
+	Key,
+	LinkState,
+	Setup,
+}
+
+var (
+	policies     = map[Class]*Policy{}
+	policiesLock = sync.RWMutex{}
+)
+
+type Policy struct {
+	Allow []*net.IPNet
+	Deny  []*net.IPNet
+}
+
+func (p *Policy) Empty() bool {
+	return len(p.Allow) == 0 && len(p.Deny) == 0
+}
+
+func (p *Policy) Check(ip net.IP) bool {
+	if p.Empty() {
+		return true
+	}
+
+	if ip == nil {
+		return false
+	}
+
+	if utils.ContainsIp(p.Deny, ip) {
+		return false
+	}
+
+	if len(p.Allow) == 0 {
+		return true
+	}
+
+	return utils.ContainsIp(p.Allow, ip)
+}
+
+func Set(class Class, pol *Policy) {
+	policiesLock.Lock()
+	policies[class] = pol
+	policiesLock.Unlock()
+}
+
+func Get(class Class) (pol *Policy) {
+	policiesLock.RLock()
+	pol = policies[class]
+	policiesLock.RUnlock()
+	return
+}
+
+func Check(class Class, addr string) bool {
+	pol := Get(class)
+	if pol == nil {
+		return true
+	}
+
+	return pol.Check(net.ParseIP(addr))
+}
@@ -0,0 +1,164 @@
+//go:build linux
+
+package privdrop
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-web/errortypes"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	landlockReadAccess = unix.LANDLOCK_ACCESS_FS_READ_FILE |
+		unix.LANDLOCK_ACCESS_FS_READ_DIR
+	landlockAbiV1 = unix.LANDLOCK_ACCESS_FS_EXECUTE |
+		unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+		unix.LANDLOCK_ACCESS_FS_READ_FILE |
+		unix.LANDLOCK_ACCESS_FS_READ_DIR |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_DIR |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_FILE |
+		unix.LANDLOCK_ACCESS_FS_MAKE_CHAR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_DIR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_REG |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_FIFO |
+		unix.LANDLOCK_ACCESS_FS_MAKE_BLOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SYM
+)
+
+func landlockAccess() (access uint64, err error) {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET,
+		0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		err = &errortypes.UnknownError{
+			errors.Wrap(errno, "privdrop: Landlock not supported"),
+		}
+		return
+	}
+
+	access = landlockAbiV1
+	if abi >= 2 {
+		access |= unix.LANDLOCK_ACCESS_FS_REFER
+	}
+	if abi >= 3 {
+		access |= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	}
+	if abi >= 5 {
+		access |= unix.LANDLOCK_ACCESS_FS_IOCTL_DEV
+	}
+
+	return
+}
+
+func landlockAddPath(ruleset int, path string, access uint64) (err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"path": path,
+		}).Debug("privdrop: Skipping missing Landlock path")
+		err = nil
+		return
+	}
+
+	if !info.IsDir() {
+		access &= unix.LANDLOCK_ACCESS_FS_EXECUTE |
+			unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+			unix.LANDLOCK_ACCESS_FS_READ_FILE |
+			unix.LANDLOCK_ACCESS_FS_TRUNCATE |
+			unix.LANDLOCK_ACCESS_FS_IOCTL_DEV
+	}
+
+	fd, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		err = &errortypes.ReadError{
+			errors.Wrapf(err, "privdrop: Failed to open '%s'", path),
+		}
+		return
+	}
+	defer unix.Close(fd)
+
+	attr := unix.LandlockPathBeneathAttr{
+		Allowed_access: access,
+		Parent_fd:      int32(fd),
+	}
+
+	_, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE,
+		uintptr(ruleset), unix.LANDLOCK_RULE_PATH_BENEATH,
+		uintptr(unsafe.Pointer(&attr)), 0, 0, 0)
+	if errno != 0 {
+		err = &errortypes.UnknownError{
+			errors.Wrapf(errno, "privdrop: Failed to add Landlock "+
+				"rule for '%s'", path),
+		}
+		return
+	}
+
+	return
+}
+
+func landlockRuleset(readPaths, writePaths []string) (
+	ruleset int, err error) {
+
+	access, err := landlockAccess()
+	if err != nil {
+		return
+	}
+
+	attr := unix.LandlockRulesetAttr{
+		Access_fs: access,
+	}
+
+	fd, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET,
+		uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr.Access_fs), 0)
+	if errno != 0 {
+		err = &errortypes.UnknownError{
+			errors.Wrap(errno, "privdrop: Failed to create Landlock ruleset"),
+		}
+		return
+	}
+	ruleset = int(fd)
+
+	for _, path := range readPaths {
+		err = landlockAddPath(ruleset, path, access&landlockReadAccess)
+		if err != nil {
+			unix.Close(ruleset)
+			return
+		}
+	}
+
+	for _, path := range writePaths {
+		err = landlockAddPath(ruleset, path,
+			access&^unix.LANDLOCK_ACCESS_FS_EXECUTE)
+		if err != nil {
+			unix.Close(ruleset)
+			return
+		}
+	}
+
+	return
+}
+
+func landlockRestrict(ruleset int) (err error) {
+	_, _, errno := syscall.AllThreadsSyscall(
+		unix.SYS_LANDLOCK_RESTRICT_SELF, uintptr(ruleset), 0, 0)
+	if errno == syscall.ENOTSUP {
+		err = &errortypes.UnknownError{
+			errors.New("privdrop: Thread wide restrictions require " +
+				"a build with CGO_ENABLED=0"),
+		}
+		return
+	}
+	if errno != 0 {
+		err = &errortypes.UnknownError{
+			errors.Wrap(errno, "privdrop: Failed to apply Landlock ruleset"),
+		}
+		return
+	}
+
+	return
+}
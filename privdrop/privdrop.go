@@ -0,0 +1,60 @@
+package privdrop
+
+import (
+	"os/user"
+	"strconv"
+
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-web/errortypes"
+)
+
+type Options struct {
+	User       string
+	Group      string
+	NoNewPrivs bool
+	Landlock   bool
+	ReadPaths  []string
+	WritePaths []string
+}
+
+func (o *Options) Enabled() bool {
+	return o.User != "" || o.Group != "" || o.NoNewPrivs || o.Landlock
+}
+
+func lookupIds(userName, groupName string) (uid, gid int, err error) {
+	uid = -1
+	gid = -1
+
+	if userName != "" {
+		usr, e := user.Lookup(userName)
+		if e != nil {
+			usr, e = user.LookupId(userName)
+		}
+		if e != nil {
+			err = &errortypes.ParseError{
+				errors.Wrapf(e, "privdrop: Unknown user '%s'", userName),
+			}
+			return
+		}
+
+		uid, _ = strconv.Atoi(usr.Uid)
+		gid, _ = strconv.Atoi(usr.Gid)
+	}
+
+	if groupName != "" {
+		grp, e := user.LookupGroup(groupName)
+		if e != nil {
+			grp, e = user.LookupGroupId(groupName)
+		}
+		if e != nil {
+			err = &errortypes.ParseError{
+				errors.Wrapf(e, "privdrop: Unknown group '%s'", groupName),
+			}
+			return
+		}
+
+		gid, _ = strconv.Atoi(grp.Gid)
+	}
+
+	return
+}
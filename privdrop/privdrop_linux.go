@@ -0,0 +1,135 @@
+//go:build linux
+
+package privdrop
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-web/errortypes"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+func setIds(uid, gid int) (err error) {
+	if (uid == -1 || uid == os.Getuid()) &&
+		(gid == -1 || gid == os.Getgid()) {
+
+		return
+	}
+
+	if gid != -1 {
+		err = syscall.Setgroups([]int{})
+		if err != nil {
+			err = &errortypes.UnknownError{
+				errors.Wrap(err, "privdrop: Failed to clear groups"),
+			}
+			return
+		}
+
+		err = syscall.Setgid(gid)
+		if err != nil {
+			err = &errortypes.UnknownError{
+				errors.Wrap(err, "privdrop: Failed to set group"),
+			}
+			return
+		}
+	}
+
+	if uid != -1 {
+		err = syscall.Setuid(uid)
+		if err != nil {
+			err = &errortypes.UnknownError{
+				errors.Wrap(err, "privdrop: Failed to set user"),
+			}
+			return
+		}
+	}
+
+	if (uid != -1 && os.Getuid() != uid) ||
+		(gid != -1 && os.Getgid() != gid) {
+
+		err = &errortypes.UnknownError{
+			errors.New("privdrop: Process ids unchanged after drop"),
+		}
+		return
+	}
+
+	if uid != 0 && uid != -1 && syscall.Setuid(0) == nil {
+		err = &errortypes.UnknownError{
+			errors.New("privdrop: Able to regain root after drop"),
+		}
+		return
+	}
+
+	return
+}
+
+func setNoNewPrivs() (err error) {
+	_, _, errno := syscall.AllThreadsSyscall(syscall.SYS_PRCTL,
+		unix.PR_SET_NO_NEW_PRIVS, 1, 0)
+	if errno == syscall.ENOTSUP {
+		err = &errortypes.UnknownError{
+			errors.New("privdrop: Thread wide restrictions require " +
+				"a build with CGO_ENABLED=0"),
+		}
+		return
+	}
+	if errno != 0 {
+		err = &errortypes.UnknownError{
+			errors.Wrap(errno, "privdrop: Failed to set no_new_privs"),
+		}
+		return
+	}
+
+	return
+}
+
+func Drop(opts *Options) (err error) {
+	if !opts.Enabled() {
+		return
+	}
+
+	uid, gid, err := lookupIds(opts.User, opts.Group)
+	if err != nil {
+		return
+	}
+
+	var ruleset int
+	if opts.Landlock {
+		ruleset, err = landlockRuleset(opts.ReadPaths, opts.WritePaths)
+		if err != nil {
+			return
+		}
+		defer unix.Close(ruleset)
+	}
+
+	err = setIds(uid, gid)
+	if err != nil {
+		return
+	}
+
+	if opts.NoNewPrivs || opts.Landlock {
+		err = setNoNewPrivs()
+		if err != nil {
+			return
+		}
+	}
+
+	if opts.Landlock {
+		err = landlockRestrict(ruleset)
+		if err != nil {
+			return
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"uid":          os.Getuid(),
+		"gid":          os.Getgid(),
+		"no_new_privs": opts.NoNewPrivs || opts.Landlock,
+		"landlock":     opts.Landlock,
+	}).Info("privdrop: Dropped privileges")
+
+	return
+}
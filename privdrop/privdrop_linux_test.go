@@ -0,0 +1,50 @@
+//go:build linux
+
+package privdrop
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+)
+
+func TestSetIdsGroups(t *testing.T) {
+	if os.Getenv("PRIVDROP_TEST_CHILD") == "1" {
+		err := syscall.Setgroups([]int{1, 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = setIds(65534, 65534)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		groups, err := syscall.Getgroups()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(groups) != 0 {
+			t.Fatalf("supplementary groups %v not cleared", groups)
+		}
+		if os.Getuid() != 65534 || os.Getgid() != 65534 {
+			t.Fatalf("ids %d:%d, expected 65534:65534",
+				os.Getuid(), os.Getgid())
+		}
+
+		return
+	}
+
+	if os.Getuid() != 0 {
+		t.Skip("requires root")
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestSetIdsGroups$")
+	cmd.Env = append(os.Environ(), "PRIVDROP_TEST_CHILD=1")
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("privilege drop failed: %s\n%s", err, output)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !linux
+
+package privdrop
+
+import (
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-web/errortypes"
+)
+
+func Drop(opts *Options) (err error) {
+	if !opts.Enabled() {
+		return
+	}
+
+	err = &errortypes.UnknownError{
+		errors.New("privdrop: Privilege dropping not supported " +
+			"on this platform"),
+	}
+	return
+}
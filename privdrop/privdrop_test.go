@@ -0,0 +1,83 @@
+package privdrop
+
+import (
+	"os/user"
+	"strconv"
+	"testing"
+)
+
+func TestOptionsEnabled(t *testing.T) {
+	tests := []struct {
+		opts     Options
+		expected bool
+	}{
+		{Options{}, false},
+		{Options{ReadPaths: []string{"/etc"}}, false},
+		{Options{User: "nobody"}, true},
+		{Options{Group: "nogroup"}, true},
+		{Options{NoNewPrivs: true}, true},
+		{Options{Landlock: true}, true},
+	}
+
+	for _, test := range tests {
+		if test.opts.Enabled() != test.expected {
+			t.Errorf("options %+v enabled %t, expected %t",
+				test.opts, !test.expected, test.expected)
+		}
+	}
+}
+
+func TestLookupIds(t *testing.T) {
+	usr, err := user.Current()
+	if err != nil {
+		t.Skip("current user unavailable")
+	}
+
+	grp, err := user.LookupGroupId(usr.Gid)
+	if err != nil {
+		t.Skip("current group unavailable")
+	}
+
+	uid, _ := strconv.Atoi(usr.Uid)
+	gid, _ := strconv.Atoi(usr.Gid)
+
+	tests := []struct {
+		user  string
+		group string
+		uid   int
+		gid   int
+		err   bool
+	}{
+		{"", "", -1, -1, false},
+		{usr.Username, "", uid, gid, false},
+		{usr.Uid, "", uid, gid, false},
+		{"", grp.Name, -1, gid, false},
+		{"", grp.Gid, -1, gid, false},
+		{usr.Uid, grp.Name, uid, gid, false},
+		{"pritunl-web-missing", "", -1, -1, true},
+		{"", "pritunl-web-missing", -1, -1, true},
+		{usr.Username, "pritunl-web-missing", uid, gid, true},
+	}
+
+	for _, test := range tests {
+		testUid, testGid, err := lookupIds(test.user, test.group)
+		if test.err {
+			if err == nil {
+				t.Errorf("lookup '%s' '%s' succeeded, expected error",
+					test.user, test.group)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("lookup '%s' '%s' failed: %s",
+				test.user, test.group, err)
+			continue
+		}
+
+		if testUid != test.uid || testGid != test.gid {
+			t.Errorf("lookup '%s' '%s' returned %d:%d, expected %d:%d",
+				test.user, test.group, testUid, testGid,
+				test.uid, test.gid)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-web/errortypes"
+	"github.com/pritunl/pritunl-web/utils"
+)
+
+var (
+	v1Prefix    = []byte("PROXY ")
+	v2Signature = []byte{
+		0x0d, 0x0a, 0x0d, 0x0a, 0x00, 0x0d, 0x0a, 0x51, 0x55, 0x49, 0x54, 0x0a,
+	}
+)
+
+const (
+	v1MaxLen      = 107
+	headerTimeout = 10 * time.Second
+)
+
+type Listener struct {
+	net.Listener
+	Trusted []*net.IPNet
+}
+
+func (l *Listener) Accept() (conn net.Conn, err error) {
+	conn, err = l.Listener.Accept()
+	if err != nil {
+		return
+	}
+
+	addr, ok := conn.RemoteAddr().(*net.TCPAddr)
+	if !ok || !utils.ContainsIp(l.Trusted, addr.IP) {
+		return
+	}
+
+	conn = &Conn{
+		Conn:   conn,
+		reader: bufio.NewReader(conn),
+	}
+
+	return
+}
+
+type Conn struct {
+	net.Conn
+	reader     *bufio.Reader
+	once       sync.Once
+	err        error
+	remoteAddr net.Addr
+	localAddr  net.Addr
+}
+
+func (c *Conn) init() {
+	c.once.Do(func() {
+		c.Conn.SetReadDeadline(time.Now().Add(headerTimeout))
+		c.err = c.readHeader()
+		c.Conn.SetReadDeadline(time.Time{})
+	})
+}
+
+func (c *Conn) Read(b []byte) (n int, err error) {
+	c.init()
+	if c.err != nil {
+		err = c.err
+		return
+	}
+
+	return c.reader.Read(b)
+}
+
+func (c *Conn) RemoteAddr() net.Addr {
+	c.init()
+	if c.remoteAddr != nil {
+		return c.remoteAddr
+	}
+	return c.Conn.RemoteAddr()
+}
+
+func (c *Conn) LocalAddr() net.Addr {
+	c.init()
+	if c.localAddr != nil {
+		return c.localAddr
+	}
+	return c.Conn.LocalAddr()
+}
+
+func (c *Conn) readHeader() (err error) {
+	first, err := c.reader.Peek(1)
+	if err != nil {
+		if err == io.EOF {
+			err = nil
+		}
+		return
+	}
+
+	switch first[0] {
+	case v1Prefix[0]:
+		prefix, e := c.reader.Peek(len(v1Prefix))
+		if e != nil || !bytes.Equal(prefix, v1Prefix) {
+			return
+		}
+		err = c.readV1()
+	case v2Signature[0]:
+		sig, e := c.reader.Peek(len(v2Signature))
+		if e != nil || !bytes.Equal(sig, v2Signature) {
+			return
+		}
+		err = c.readV2()
+	}
+
+	return
+}
+
+func (c *Conn) readV1() (err error) {
+	line := ""
+	for len(line) < v1MaxLen {
+		b, e := c.reader.ReadByte()
+		if e != nil {
+			err = &errortypes.ParseError{
+				errors.Wrap(e, "proxyproto: Failed to read v1 header"),
+			}
+			return
+		}
+
+		line += string(b)
+		if b == '\n' {
+			break
+		}
+	}
+
+	if !strings.HasSuffix(line, "\r\n") {
+		err = &errortypes.ParseError{
+			errors.New("proxyproto: Invalid v1 header termination"),
+		}
+		return
+	}
+
+	fields := strings.Fields(strings.TrimSuffix(line, "\r\n"))
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return
+	}
+
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		err = &errortypes.ParseError{
+			errors.New("proxyproto: Invalid v1 header"),
+		}
+		return
+	}
+
+	srcIp := net.ParseIP(fields[2])
+	dstIp := net.ParseIP(fields[3])
+	srcPort, e1 := strconv.ParseUint(fields[4], 10, 16)
+	dstPort, e2 := strconv.ParseUint(fields[5], 10, 16)
+	if srcIp == nil || dstIp == nil || e1 != nil || e2 != nil {
+		err = &errortypes.ParseError{
+			errors.New("proxyproto: Invalid v1 header address"),
+		}
+		return
+	}
+
+	c.remoteAddr = &net.TCPAddr{
+		IP:   srcIp,
+		Port: int(srcPort),
+	}
+	c.localAddr = &net.TCPAddr{
+		IP:   dstIp,
+		Port: int(dstPort),
+	}
+
+	return
+}
+
+func (c *Conn) readV2() (err error) {
+	header := make([]byte, 16)
+	_, err = io.ReadFull(c.reader, header)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrap(err, "proxyproto: Failed to read v2 header"),
+		}
+		return
+	}
+
+	if header[12]>>4 != 2 {
+		err = &errortypes.ParseError{
+			errors.New("proxyproto: Invalid v2 header version"),
+		}
+		return
+	}
+
+	command := header[12] & 0x0f
+	family := header[13] >> 4
+	transport := header[13] & 0x0f
+	length := binary.BigEndian.Uint16(header[14:16])
+
+	payload := make([]byte, length)
+	_, err = io.ReadFull(c.reader, payload)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrap(err, "proxyproto: Failed to read v2 addresses"),
+		}
+		return
+	}
+
+	if command == 0x0 || transport != 0x1 {
+		return
+	}
+	if command != 0x1 {
+		err = &errortypes.ParseError{
+			errors.New("proxyproto: Invalid v2 header command"),
+		}
+		return
+	}
+
+	var ipLen int
+	switch family {
+	case 0x1:
+		ipLen = net.IPv4len
+	case 0x2:
+		ipLen = net.IPv6len
+	default:
+		return
+	}
+
+	if len(payload) < ipLen*2+4 {
+		err = &errortypes.ParseError{
+			errors.New("proxyproto: Invalid v2 header length"),
+		}
+		return
+	}
+
+	c.remoteAddr = &net.TCPAddr{
+		IP:   net.IP(payload[:ipLen]),
+		Port: int(binary.BigEndian.Uint16(payload[ipLen*2:])),
+	}
+	c.localAddr = &net.TCPAddr{
+		IP:   net.IP(payload[ipLen : ipLen*2]),
+		Port: int(binary.BigEndian.Uint16(payload[ipLen*2+2:])),
+	}
+
+	return
+}
@@ -0,0 +1,232 @@
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/pritunl/pritunl-web/utils"
+)
+
+func newTestConn(t testing.TB, data []byte) *Conn {
+	local, remote := net.Pipe()
+	t.Cleanup(func() {
+		local.Close()
+		remote.Close()
+	})
+
+	return &Conn{
+		Conn:   local,
+		reader: bufio.NewReader(bytes.NewReader(data)),
+	}
+}
+
+func v2Header(command, family byte, length int, payload []byte) []byte {
+	header := append([]byte{}, v2Signature...)
+	header = append(header, command, family, 0, 0)
+	binary.BigEndian.PutUint16(header[14:], uint16(length))
+	return append(header, payload...)
+}
+
+func v2Addrs(src, dst net.IP, srcPort, dstPort uint16) []byte {
+	payload := append(append([]byte{}, src...), dst...)
+	payload = binary.BigEndian.AppendUint16(payload, srcPort)
+	return binary.BigEndian.AppendUint16(payload, dstPort)
+}
+
+func v1Line(length int) string {
+	line := "PROXY TCP4 192.0.2.1 198.51.100.2 56324 443"
+	return line + strings.Repeat(" ", length-len(line)-2) + "\r\n"
+}
+
+func TestReadHeader(t *testing.T) {
+	src4 := net.ParseIP("192.0.2.1").To4()
+	dst4 := net.ParseIP("198.51.100.2").To4()
+	src6 := net.ParseIP("2001:db8::1")
+	dst6 := net.ParseIP("2001:db8::2")
+
+	tests := []struct {
+		name   string
+		data   []byte
+		remote string
+		local  string
+		fail   bool
+	}{
+		{"no header", []byte("GET / HTTP/1.1\r\n"), "", "", false},
+		{"v1 tcp4",
+			[]byte("PROXY TCP4 192.0.2.1 198.51.100.2 56324 443\r\n"),
+			"192.0.2.1:56324", "198.51.100.2:443", false},
+		{"v1 tcp6",
+			[]byte("PROXY TCP6 2001:db8::1 2001:db8::2 56324 443\r\n"),
+			"[2001:db8::1]:56324", "[2001:db8::2]:443", false},
+		{"v1 unknown", []byte("PROXY UNKNOWN\r\n"), "", "", false},
+		{"v1 max length", []byte(v1Line(107)),
+			"192.0.2.1:56324", "198.51.100.2:443", false},
+		{"v1 too long", []byte(v1Line(108)), "", "", true},
+		{"v1 missing cr",
+			[]byte("PROXY TCP4 192.0.2.1 198.51.100.2 56324 443\n"),
+			"", "", true},
+		{"v1 unterminated",
+			[]byte("PROXY TCP4 192.0.2.1 198.51.100.2 56324 443"),
+			"", "", true},
+		{"v1 invalid address",
+			[]byte("PROXY TCP4 192.0.2.1 invalid 56324 443\r\n"),
+			"", "", true},
+		{"v1 invalid port",
+			[]byte("PROXY TCP4 192.0.2.1 198.51.100.2 70000 443\r\n"),
+			"", "", true},
+		{"v2 tcp4", v2Header(0x21, 0x11, 12,
+			v2Addrs(src4, dst4, 56324, 443)),
+			"192.0.2.1:56324", "198.51.100.2:443", false},
+		{"v2 tcp6", v2Header(0x21, 0x21, 36,
+			v2Addrs(src6, dst6, 56324, 443)),
+			"[2001:db8::1]:56324", "[2001:db8::2]:443", false},
+		{"v2 local", v2Header(0x20, 0x00, 0, nil), "", "", false},
+		{"v2 local with addresses", v2Header(0x20, 0x11, 12,
+			v2Addrs(src4, dst4, 56324, 443)), "", "", false},
+		{"v2 unspec", v2Header(0x21, 0x00, 0, nil), "", "", false},
+		{"v2 truncated addresses", v2Header(0x21, 0x11, 12,
+			v2Addrs(src4, dst4, 56324, 443)[:6]), "", "", true},
+		{"v2 short addresses", v2Header(0x21, 0x11, 6,
+			v2Addrs(src4, dst4, 56324, 443)[:6]), "", "", true},
+		{"v2 truncated header", v2Header(0x21, 0x11, 12, nil)[:14],
+			"", "", true},
+		{"v2 invalid version", v2Header(0x11, 0x11, 12,
+			v2Addrs(src4, dst4, 56324, 443)), "", "", true},
+		{"v2 invalid command", v2Header(0x22, 0x11, 12,
+			v2Addrs(src4, dst4, 56324, 443)), "", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conn := newTestConn(t, append(test.data, "body"...))
+			conn.init()
+
+			if test.fail {
+				if conn.err == nil {
+					t.Fatal("invalid header accepted")
+				}
+				return
+			}
+			if conn.err != nil {
+				t.Fatal(conn.err)
+			}
+
+			remote := ""
+			if conn.remoteAddr != nil {
+				remote = conn.remoteAddr.String()
+			}
+			local := ""
+			if conn.localAddr != nil {
+				local = conn.localAddr.String()
+			}
+
+			if remote != test.remote || local != test.local {
+				t.Fatalf("addresses %q %q, expected %q %q",
+					remote, local, test.remote, test.local)
+			}
+
+			rest, err := io.ReadAll(conn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.HasSuffix(rest, []byte("body")) {
+				t.Fatalf("payload %q not preserved", rest)
+			}
+			if test.remote != "" && string(rest) != "body" {
+				t.Fatalf("header not consumed, payload %q", rest)
+			}
+		})
+	}
+}
+
+func TestListenerUntrusted(t *testing.T) {
+	trusted, err := utils.ParseCidrs("192.0.2.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener := &Listener{
+		Listener: inner,
+		Trusted:  trusted,
+	}
+	defer listener.Close()
+
+	data := "PROXY TCP4 192.0.2.1 198.51.100.2 56324 443\r\nbody"
+	go func() {
+		conn, e := net.Dial("tcp", inner.Addr().String())
+		if e != nil {
+			return
+		}
+		conn.Write([]byte(data))
+		conn.Close()
+	}()
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, ok := conn.(*Conn); ok {
+		t.Fatal("untrusted connection wrapped")
+	}
+
+	host, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+	if host != "127.0.0.1" {
+		t.Fatalf("remote address %s, expected peer address",
+			conn.RemoteAddr())
+	}
+
+	rest, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(rest) != data {
+		t.Fatalf("payload %q, expected unchanged %q", rest, data)
+	}
+}
+
+func FuzzReadHeader(f *testing.F) {
+	f.Add([]byte("GET / HTTP/1.1\r\n"))
+	f.Add([]byte("PROXY TCP4 192.0.2.1 198.51.100.2 56324 443\r\n"))
+	f.Add([]byte("PROXY TCP6 2001:db8::1 2001:db8::2 56324 443\r\n"))
+	f.Add([]byte("PROXY UNKNOWN\r\n"))
+	f.Add(v2Header(0x21, 0x11, 12, v2Addrs(net.IPv4(192, 0, 2, 1).To4(),
+		net.IPv4(198, 51, 100, 2).To4(), 56324, 443)))
+	f.Add(v2Header(0x20, 0x00, 0, nil))
+	f.Add(v2Header(0x21, 0x11, 12, nil))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		conn := newTestConn(t, append(data, "body"...))
+		conn.init()
+		if conn.err != nil {
+			return
+		}
+
+		rest, err := io.ReadAll(conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasSuffix(rest, []byte("body")) ||
+			!bytes.HasSuffix(append(data, "body"...), rest) {
+
+			t.Fatalf("payload %q not a suffix of input", rest)
+		}
+
+		if conn.remoteAddr != nil {
+			addr := conn.remoteAddr.(*net.TCPAddr)
+			if addr.IP == nil || addr.Port < 0 || addr.Port > 65535 {
+				t.Fatalf("invalid remote address %s", addr)
+			}
+		}
+	})
+}
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-web/capture"
+	"github.com/pritunl/pritunl-web/config"
+	"github.com/pritunl/pritunl-web/constants"
+	"github.com/pritunl/pritunl-web/fakebackend"
+	"github.com/pritunl/pritunl-web/handlers"
+	"github.com/sirupsen/logrus"
+)
+
+var replayIgnoreHeaders = map[string]bool{
+	"Accept-Encoding":     true,
+	"Connection":          true,
+	"Content-Length":      true,
+	"User-Agent":          true,
+	"Pr-Forwarded-For":    true,
+	"Pr-Forwarded-Header": true,
+}
+
+type replayBackend struct {
+	*fakebackend.Backend
+	lock     sync.Mutex
+	expected []*capture.Entry
+}
+
+func newReplayBackend() (b *replayBackend) {
+	b = &replayBackend{
+		Backend: fakebackend.New(),
+	}
+	b.Respond = b.respond
+	return
+}
+
+func (b *replayBackend) Reset(expected []*capture.Entry) {
+	b.lock.Lock()
+	b.expected = expected
+	b.lock.Unlock()
+	b.Backend.Reset()
+}
+
+func (b *replayBackend) Received() (received []*capture.Message) {
+	for _, req := range b.Requests() {
+		received = append(received, &capture.Message{
+			Method:  req.Method,
+			Path:    req.EscapedPath,
+			Query:   capture.RedactQuery(req.RawQuery),
+			Headers: capture.RedactHeaders(req.Header),
+			Body:    capture.RedactBody(req.Body),
+		})
+	}
+	return
+}
+
+func (b *replayBackend) respond(w http.ResponseWriter,
+	req *fakebackend.Request, index int) {
+
+	b.lock.Lock()
+	var resp *capture.Message
+	if index < len(b.expected) {
+		resp = b.expected[index].Response
+	}
+	b.lock.Unlock()
+
+	if resp == nil {
+		http.Error(w, "Unexpected backend request", 502)
+		return
+	}
+
+	for key, vals := range resp.Headers {
+		if key == "Content-Length" {
+			continue
+		}
+		for _, val := range vals {
+			w.Header().Add(key, val)
+		}
+	}
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body)
+}
+
+type replaySession struct {
+	token string
+	csrf  string
+}
+
+func (s *replaySession) cookies(val string) string {
+	cookies, err := http.ParseCookie(val)
+	if err != nil {
+		return val
+	}
+
+	pairs := []string{}
+	for _, cookie := range cookies {
+		if cookie.Value == capture.Redacted &&
+			strings.TrimPrefix(cookie.Name, "__Host-") == "token" {
+
+			cookie.Value = s.token
+		}
+		pairs = append(pairs, cookie.Name+"="+cookie.Value)
+	}
+
+	return strings.Join(pairs, "; ")
+}
+
+func replayRequest(client *http.Client, target string,
+	session *replaySession, msg *capture.Message) (err error) {
+
+	reqUrl := strings.TrimSuffix(target, "/") + msg.Path
+	if msg.Query != "" {
+		reqUrl += "?" + msg.Query
+	}
+
+	var body io.Reader
+	if msg.Body != nil {
+		body = bytes.NewReader(msg.Body)
+	}
+
+	req, err := http.NewRequest(msg.Method, reqUrl, body)
+	if err != nil {
+		return
+	}
+	req.Host = msg.Host
+
+	for key, vals := range msg.Headers {
+		switch key {
+		case "Connection", "Content-Length", "Transfer-Encoding":
+			continue
+		}
+
+		for _, val := range vals {
+			if session != nil {
+				if key == "Cookie" {
+					val = session.cookies(val)
+				} else if key == "Csrf-Token" &&
+					val == capture.Redacted &&
+					constants.Session.Csrf == "token" {
+
+					val += "." + session.csrf
+				}
+			}
+			req.Header.Add(key, val)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	return
+}
+
+func diffMessage(expected, actual *capture.Message) (diffs []string) {
+	if expected.Method != actual.Method {
+		diffs = append(diffs, fmt.Sprintf("method %s, expected %s",
+			actual.Method, expected.Method))
+	}
+
+	if expected.Path != actual.Path {
+		diffs = append(diffs, fmt.Sprintf("path %s, expected %s",
+			actual.Path, expected.Path))
+	}
+
+	if expected.Query != actual.Query {
+		diffs = append(diffs, fmt.Sprintf("query '%s', expected '%s'",
+			actual.Query, expected.Query))
+	}
+
+	if !bytes.Equal(expected.Body, actual.Body) {
+		diffs = append(diffs, fmt.Sprintf("body %s, expected %s",
+			actual.Body, expected.Body))
+	}
+
+	keys := map[string]bool{}
+	for key := range expected.Headers {
+		keys[key] = true
+	}
+	for key := range actual.Headers {
+		keys[key] = true
+	}
+
+	headers := []string{}
+	for key := range keys {
+		if !replayIgnoreHeaders[key] {
+			headers = append(headers, key)
+		}
+	}
+	sort.Strings(headers)
+
+	for _, key := range headers {
+		expectedVal := strings.Join(expected.Headers.Values(key), ", ")
+		actualVal := strings.Join(actual.Headers.Values(key), ", ")
+		if expectedVal != actualVal {
+			diffs = append(diffs, fmt.Sprintf("header %s '%s', expected '%s'",
+				key, actualVal, expectedVal))
+		}
+	}
+
+	return
+}
+
+func diffReplay(expected []*capture.Entry,
+	received []*capture.Message) (diffs []string) {
+
+	for i, entry := range expected {
+		if i >= len(received) {
+			diffs = append(diffs, fmt.Sprintf("missing backend request %s %s",
+				entry.Backend.Method, entry.Backend.Path))
+			continue
+		}
+
+		for _, diff := range diffMessage(entry.Backend, received[i]) {
+			diffs = append(diffs, fmt.Sprintf("%s %s: %s",
+				entry.Backend.Method, entry.Backend.Path, diff))
+		}
+	}
+
+	for _, msg := range received[min(len(expected), len(received)):] {
+		diffs = append(diffs, fmt.Sprintf("unexpected backend request %s %s",
+			msg.Method, msg.Path))
+	}
+
+	return
+}
+
+func groupEntries(entries []*capture.Entry) (groups [][]*capture.Entry) {
+	index := map[string]int{}
+
+	for _, entry := range entries {
+		i, ok := index[entry.Id]
+		if !ok {
+			i = len(groups)
+			index[entry.Id] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], entry)
+	}
+
+	return
+}
+
+func replayCommand(configPath string, args []string) int {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	flags.StringVar(&configPath, "config", configPath,
+		"Path to configuration file")
+	jsonOutput := flags.Bool("json", false, "Print report as JSON")
+	listenAddr := flags.String("listen", "127.0.0.1:9756",
+		"Address for the fake backend, set as internal_address of the "+
+			"replay target")
+	target := flags.String("target", "https://127.0.0.1",
+		"URL of the pritunl-web build to replay against")
+	insecure := flags.Bool("insecure", false,
+		"Skip verification of the replay target certificate")
+	timeout := flags.Duration("timeout", 30*time.Second,
+		"Timeout for each replayed request")
+	flags.Parse(args)
+
+	logrus.SetLevel(logrus.ErrorLevel)
+
+	report := &checkReport{
+		Ok:      true,
+		subject: "Replay",
+	}
+
+	if flags.NArg() != 1 {
+		report.add("capture", checkError, "Capture file path required")
+		report.Print(*jsonOutput)
+		return 2
+	}
+
+	conf, err := config.Load(configPath)
+	if err == nil {
+		err = applyConfig(conf)
+	}
+	if err != nil {
+		report.add("config", checkError, errors.GetMessage(err))
+		report.Print(*jsonOutput)
+		return 1
+	}
+
+	entries, err := capture.Read(flags.Arg(0))
+	if err != nil {
+		report.add("capture", checkError, errors.GetMessage(err))
+		report.Print(*jsonOutput)
+		return 1
+	}
+	report.add("capture", checkOk, fmt.Sprintf(
+		"Loaded %d backend requests", len(entries)))
+
+	var session *replaySession
+	if constants.WebSecret.Load() != nil {
+		token, csrf, e := handlers.NewSession("replay")
+		if e != nil {
+			report.add("session", checkError, errors.GetMessage(e))
+			report.Print(*jsonOutput)
+			return 1
+		}
+		session = &replaySession{
+			token: token,
+			csrf:  csrf,
+		}
+
+		if constants.Session.TokenBinding == "enforce" {
+			report.add("session", checkWarning,
+				"Token binding enforced, replayed sessions are unbound "+
+					"and will be rejected")
+		}
+	} else {
+		report.add("session", checkWarning,
+			"Web secret not configured, sessions will not be replayed")
+	}
+
+	listener, err := net.Listen("tcp", *listenAddr)
+	if err != nil {
+		report.add("listen", checkError, err.Error())
+		report.Print(*jsonOutput)
+		return 1
+	}
+
+	backend := newReplayBackend()
+	server := &http.Server{
+		Handler: backend,
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: *insecure,
+			},
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+		Timeout: *timeout,
+	}
+
+	for _, group := range groupEntries(entries) {
+		clientMsg := group[0].Client
+		name := fmt.Sprintf("%s %s", clientMsg.Method, clientMsg.Path)
+
+		if clientMsg.Headers.Get("Auth-Signature") != "" {
+			report.add(name, checkWarning,
+				"Signed API request skipped, the captured signature is "+
+					"redacted and its timestamp has expired")
+			continue
+		}
+
+		backend.Reset(group)
+
+		err = replayRequest(client, *target, session, clientMsg)
+		if err != nil {
+			report.add(name, checkError, err.Error())
+			continue
+		}
+
+		diffs := diffReplay(group, backend.Received())
+		if len(diffs) > 0 {
+			report.add(name, checkError, strings.Join(diffs, "; "))
+		} else {
+			report.add(name, checkOk, "Backend requests match")
+		}
+	}
+
+	report.Print(*jsonOutput)
+	if !report.Ok {
+		return 1
+	}
+	return 0
+}
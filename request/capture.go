@@ -0,0 +1,89 @@
+package request
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-web/capture"
+	"github.com/pritunl/tools/logger"
+)
+
+func captureClientBody(c *gin.Context) (data []byte) {
+	if c.Request.Body == nil {
+		return
+	}
+
+	data, _ = io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(bytes.NewReader(data))
+
+	return
+}
+
+func captureResponseBody(resp *http.Response) (data []byte) {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		return
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		data = nil
+	}
+
+	return
+}
+
+func captureSend(c *gin.Context, clientBody []byte, req *http.Request,
+	backendBody []byte, resp *http.Response) {
+
+	id := c.GetString("capture_id")
+	if id == "" {
+		var err error
+		id, err = capture.NewId()
+		if err != nil {
+			logger.WithFields(logger.Fields{
+				"error": err,
+			}).Error("request: Failed to capture request")
+			return
+		}
+		c.Set("capture_id", id)
+	}
+
+	entry := &capture.Entry{
+		Id:        id,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Client: &capture.Message{
+			Method:  c.Request.Method,
+			Host:    c.Request.Host,
+			Path:    c.Request.URL.EscapedPath(),
+			Query:   capture.RedactQuery(c.Request.URL.RawQuery),
+			Headers: capture.RedactHeaders(c.Request.Header),
+			Body:    capture.RedactBody(clientBody),
+		},
+		Backend: &capture.Message{
+			Method:  req.Method,
+			Path:    req.URL.EscapedPath(),
+			Query:   capture.RedactQuery(req.URL.RawQuery),
+			Headers: capture.RedactHeaders(req.Header),
+			Body:    capture.RedactBody(backendBody),
+		},
+		Response: &capture.Message{
+			Status:  resp.StatusCode,
+			Headers: capture.RedactHeaders(resp.Header),
+			Body:    capture.RedactBody(captureResponseBody(resp)),
+		},
+	}
+
+	err := capture.Write(entry)
+	if err != nil {
+		logger.WithFields(logger.Fields{
+			"error": err,
+		}).Error("request: Failed to capture request")
+	}
+}
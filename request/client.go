@@ -0,0 +1,98 @@
+package request
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pritunl/pritunl-web/constants"
+	"github.com/pritunl/pritunl-web/utils"
+)
+
+var (
+	internalScheme = "http"
+	internalHost   = ""
+	client         = newClient(&http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+	})
+)
+
+func newClient(transport *http.Transport) *http.Client {
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+		Timeout: 2 * time.Minute,
+	}
+}
+
+func internalUrl(path string) string {
+	u := url.URL{
+		Scheme: internalScheme,
+		Host:   internalHost,
+		Path:   path,
+	}
+	return u.String()
+}
+
+func Init() (err error) {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	transport := &http.Transport{
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	internalAddr := constants.InternalHost
+	internalScheme = "http"
+
+	if strings.HasPrefix(internalAddr, "unix:") {
+		socketPath := strings.TrimPrefix(internalAddr, "unix:")
+
+		internalHost = "localhost"
+		transport.DialContext = func(ctx context.Context,
+			network, addr string) (net.Conn, error) {
+
+			return dialer.DialContext(ctx, "unix", socketPath)
+		}
+	} else {
+		if strings.HasPrefix(internalAddr, "https://") {
+			internalScheme = "https"
+			internalAddr = strings.TrimPrefix(internalAddr, "https://")
+		} else {
+			internalAddr = strings.TrimPrefix(internalAddr, "http://")
+		}
+
+		internalHost = strings.TrimSuffix(internalAddr, "/")
+		transport.Proxy = http.ProxyFromEnvironment
+		transport.DialContext = dialer.DialContext
+	}
+
+	if internalScheme == "https" {
+		err = loadInternalTls()
+		if err != nil {
+			return
+		}
+
+		serverName := constants.Internal.ServerName
+		if serverName == "" {
+			serverName = utils.StripPort(internalHost)
+		}
+
+		transport.TLSClientConfig = newTlsConfig(serverName)
+		transport.ForceAttemptHTTP2 = true
+	}
+
+	client = newClient(transport)
+
+	return
+}
@@ -0,0 +1,55 @@
+package request
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pritunl/pritunl-web/constants"
+)
+
+func ClientCert(r *http.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 ||
+		len(r.TLS.VerifiedChains[0]) == 0 {
+
+		return nil
+	}
+
+	return r.TLS.VerifiedChains[0][0]
+}
+
+func CertFingerprint(cert *x509.Certificate) string {
+	hash := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(hash[:])
+}
+
+func setClientCertHeaders(req, src *http.Request) {
+	cert := ClientCert(src)
+	webSecret := constants.WebSecret.Load()
+	if cert == nil || webSecret == nil {
+		return
+	}
+
+	subject := cert.Subject.String()
+	fingerprint := CertFingerprint(cert)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	hash := hmac.New(sha256.New, webSecret[:])
+	hash.Write([]byte(strings.Join([]string{
+		subject,
+		fingerprint,
+		timestamp,
+	}, "&")))
+	signature := base64.StdEncoding.EncodeToString(hash.Sum(nil))
+
+	req.Header.Set("PR-Client-Cert-Subject", subject)
+	req.Header.Set("PR-Client-Cert-Fingerprint", fingerprint)
+	req.Header.Set("PR-Client-Cert-Timestamp", timestamp)
+	req.Header.Set("PR-Client-Cert-Signature", signature)
+}
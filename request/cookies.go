@@ -0,0 +1,198 @@
+package request
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pritunl/pritunl-web/constants"
+	"golang.org/x/net/http/httpguts"
+)
+
+const hostPrefix = "__Host-"
+
+func requestSecure(r *http.Request) bool {
+	if constants.Ssl {
+		return true
+	}
+
+	if constants.ReverseProxyProtoHeader != "" && strings.ToLower(
+		r.Header.Get(constants.ReverseProxyProtoHeader)) == "https" {
+
+		return true
+	}
+
+	return false
+}
+
+func cookieSameSite(name string) http.SameSite {
+	mode, ok := constants.Cookies.SameSite[name]
+	if !ok {
+		mode = constants.Cookies.SameSite["*"]
+	}
+
+	switch strings.ToLower(mode) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "lax":
+		return http.SameSiteLaxMode
+	case "none":
+		return http.SameSiteNoneMode
+	}
+
+	return http.SameSiteDefaultMode
+}
+
+func hardenCookie(cookie *http.Cookie, secure bool) {
+	if secure {
+		cookie.Secure = true
+	}
+
+	for _, name := range constants.Cookies.HttpOnly {
+		if cookie.Name == name {
+			cookie.HttpOnly = true
+		}
+	}
+
+	sameSite := cookieSameSite(cookie.Name)
+	if sameSite != http.SameSiteDefaultMode {
+		if sameSite == http.SameSiteNoneMode && !cookie.Secure {
+			sameSite = http.SameSiteLaxMode
+		}
+		cookie.SameSite = sameSite
+	}
+}
+
+func hostPrefixCookie(cookie *http.Cookie) (prefixed, legacy *http.Cookie) {
+	if !constants.Cookies.HostPrefix || !cookie.Secure ||
+		cookie.Domain != "" || strings.HasPrefix(cookie.Name, hostPrefix) {
+
+		return cookie, nil
+	}
+
+	prefixed = &http.Cookie{}
+	*prefixed = *cookie
+	prefixed.Name = hostPrefix + cookie.Name
+	prefixed.Path = "/"
+
+	legacy = &http.Cookie{
+		Name:     cookie.Name,
+		Value:    "",
+		Path:     cookie.Path,
+		Expires:  time.Unix(0, 0),
+		MaxAge:   -1,
+		Secure:   cookie.Secure,
+		HttpOnly: cookie.HttpOnly,
+		SameSite: cookie.SameSite,
+	}
+
+	return
+}
+
+func rewriteSetCookies(header http.Header, secure bool) {
+	setCookies := header.Values("Set-Cookie")
+	if len(setCookies) == 0 {
+		return
+	}
+
+	header.Del("Set-Cookie")
+	for _, setCookie := range setCookies {
+		cookie, err := http.ParseSetCookie(setCookie)
+		if err != nil {
+			continue
+		}
+
+		hardenCookie(cookie, secure)
+		cookie, legacy := hostPrefixCookie(cookie)
+
+		if legacy != nil {
+			header.Add("Set-Cookie", legacy.String())
+		}
+		header.Add("Set-Cookie", cookie.String())
+	}
+}
+
+func filterHeaders(header http.Header) {
+	for key := range header {
+		if constants.Headers.ResponseDeny[key] {
+			header.Del(key)
+			continue
+		}
+
+		if len(constants.Headers.ResponseAllow) > 0 &&
+			!constants.Headers.ResponseAllow[key] {
+
+			header.Del(key)
+		}
+	}
+}
+
+func filterResponse(r *http.Request, header http.Header) {
+	filterHeaders(header)
+	rewriteSetCookies(header, requestSecure(r))
+}
+
+func backendCookies(r *http.Request) string {
+	cookies := r.Cookies()
+	if len(cookies) == 0 {
+		return ""
+	}
+
+	if !constants.Cookies.HostPrefix {
+		raw := r.Header.Get("Cookie")
+		if httpguts.ValidHeaderFieldValue(raw) {
+			return raw
+		}
+	}
+
+	prefixed := map[string]bool{}
+	if constants.Cookies.HostPrefix {
+		for _, cookie := range cookies {
+			if strings.HasPrefix(cookie.Name, hostPrefix) {
+				prefixed[strings.TrimPrefix(cookie.Name, hostPrefix)] = true
+			}
+		}
+	}
+
+	pairs := []string{}
+	for _, cookie := range cookies {
+		if constants.Cookies.HostPrefix &&
+			strings.HasPrefix(cookie.Name, hostPrefix) {
+
+			cookie.Name = strings.TrimPrefix(cookie.Name, hostPrefix)
+		} else if prefixed[cookie.Name] {
+			continue
+		}
+		pairs = append(pairs, cookie.String())
+	}
+
+	return strings.Join(pairs, "; ")
+}
+
+func Cookie(r *http.Request, name string) (val string, err error) {
+	if constants.Cookies.HostPrefix {
+		cookie, e := r.Cookie(hostPrefix + name)
+		if e == nil {
+			val = cookie.Value
+			return
+		}
+	}
+
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return
+	}
+	val = cookie.Value
+
+	return
+}
+
+func SetCookie(w http.ResponseWriter, r *http.Request, cookie *http.Cookie) {
+	hardenCookie(cookie, requestSecure(r))
+	cookie, legacy := hostPrefixCookie(cookie)
+
+	if legacy != nil {
+		http.SetCookie(w, legacy)
+	}
+	http.SetCookie(w, cookie)
+}
@@ -0,0 +1,42 @@
+package request
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pritunl/pritunl-web/constants"
+)
+
+func TestBackendCookies(t *testing.T) {
+	tests := []struct {
+		name       string
+		hostPrefix bool
+		header     string
+		expected   string
+	}{
+		{"raw", false, `a=1;b="x y";token=abc`, `a=1;b="x y";token=abc`},
+		{"raw prefixed", false, "__Host-token=new; token=old",
+			"__Host-token=new; token=old"},
+		{"invalid", false, "token=\x01;a=1", "a=1"},
+		{"prefixed", true, "__Host-token=new; token=old; a=1",
+			"token=new; a=1"},
+		{"empty", false, "", ""},
+	}
+
+	for _, test := range tests {
+		constants.Cookies.HostPrefix = test.hostPrefix
+
+		req := httptest.NewRequest("GET", "/", nil)
+		if test.header != "" {
+			req.Header.Set("Cookie", test.header)
+		}
+
+		cookies := backendCookies(req)
+		if cookies != test.expected {
+			t.Errorf("%s: cookies %q, expected %q",
+				test.name, cookies, test.expected)
+		}
+	}
+
+	constants.Cookies.HostPrefix = false
+}
@@ -0,0 +1,110 @@
+package request
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-web/constants"
+	"github.com/pritunl/pritunl-web/utils"
+)
+
+func parseForwardedAddr(val string) string {
+	val = strings.TrimSpace(val)
+	val = strings.Trim(val, "\"")
+
+	if strings.HasPrefix(val, "[") {
+		end := strings.Index(val, "]")
+		if end == -1 {
+			return ""
+		}
+		return val[1:end]
+	}
+
+	if strings.Count(val, ":") == 1 {
+		val = val[:strings.Index(val, ":")]
+	}
+
+	return val
+}
+
+func parseForwarded(vals []string) (chain []string) {
+	for _, val := range vals {
+		for _, elem := range strings.Split(val, ",") {
+			for _, pair := range strings.Split(elem, ";") {
+				pair = strings.TrimSpace(pair)
+				if len(pair) < 4 || !strings.EqualFold(pair[:4], "for=") {
+					continue
+				}
+
+				chain = append(chain, parseForwardedAddr(pair[4:]))
+			}
+		}
+	}
+
+	return
+}
+
+func parseForwardedFor(vals []string) (chain []string) {
+	for _, val := range vals {
+		for _, elem := range strings.Split(val, ",") {
+			chain = append(chain, parseForwardedAddr(elem))
+		}
+	}
+
+	return
+}
+
+func forwardedChain(r *http.Request) []string {
+	if constants.ReverseProxyHeader != "" {
+		vals := r.Header.Values(constants.ReverseProxyHeader)
+		if strings.EqualFold(constants.ReverseProxyHeader, "Forwarded") {
+			return parseForwarded(vals)
+		}
+		return parseForwardedFor(vals)
+	}
+
+	vals := r.Header.Values("Forwarded")
+	if len(vals) > 0 {
+		return parseForwarded(vals)
+	}
+
+	return parseForwardedFor(r.Header.Values("X-Forwarded-For"))
+}
+
+func ParseClientIp(r *http.Request) string {
+	clientIp := parseRemoteAddr(r.RemoteAddr)
+	if !utils.ContainsIp(constants.Proxy.Trusted, net.ParseIP(clientIp)) {
+		return clientIp
+	}
+
+	chain := forwardedChain(r)
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip := net.ParseIP(chain[i])
+		if ip == nil {
+			break
+		}
+
+		clientIp = ip.String()
+		if !utils.ContainsIp(constants.Proxy.Trusted, ip) {
+			break
+		}
+	}
+
+	return clientIp
+}
+
+func ClientIp(c *gin.Context) string {
+	clientIp := c.GetString("client_ip")
+	if clientIp == "" {
+		clientIp = ParseClientIp(c.Request)
+		c.Set("client_ip", clientIp)
+	}
+	return clientIp
+}
+
+func setForwardedHeaders(req *http.Request, clientIp string) {
+	req.Header.Set("PR-Forwarded-Header", clientIp)
+	req.Header.Set("PR-Forwarded-For", clientIp)
+}
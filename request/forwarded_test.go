@@ -0,0 +1,76 @@
+package request
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pritunl/pritunl-web/constants"
+	"github.com/pritunl/pritunl-web/utils"
+)
+
+func TestParseClientIp(t *testing.T) {
+	trusted, err := utils.ParseCidrs("10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		trusted    bool
+		remoteAddr string
+		header     string
+		expected   string
+	}{
+		{"untrusted direct", false, "198.51.100.7:443", "", "198.51.100.7"},
+		{"untrusted spoofed", false, "198.51.100.7:443", "203.0.113.9",
+			"198.51.100.7"},
+		{"untrusted spoofed chain", false, "198.51.100.7:443",
+			"203.0.113.9, 10.0.0.3", "198.51.100.7"},
+		{"trusted peer", true, "10.0.0.2:443", "203.0.113.9, 10.0.0.3",
+			"203.0.113.9"},
+		{"untrusted peer", true, "198.51.100.7:443", "203.0.113.9",
+			"198.51.100.7"},
+		{"trusted peer invalid", true, "10.0.0.2:443", "invalid",
+			"10.0.0.2"},
+	}
+
+	defer func() {
+		constants.ReverseProxyHeader = ""
+		constants.Proxy.Trusted = nil
+	}()
+	constants.ReverseProxyHeader = "X-Forwarded-For"
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			constants.Proxy.Trusted = nil
+			if test.trusted {
+				constants.Proxy.Trusted = trusted
+			}
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = test.remoteAddr
+			if test.header != "" {
+				req.Header.Set("X-Forwarded-For", test.header)
+			}
+
+			clientIp := ParseClientIp(req)
+			if clientIp != test.expected {
+				t.Fatalf("client ip %q, expected %q",
+					clientIp, test.expected)
+			}
+
+			backendReq := httptest.NewRequest("GET", "/", nil)
+			setForwardedHeaders(backendReq, clientIp)
+			for _, key := range []string{
+				"PR-Forwarded-For",
+				"PR-Forwarded-Header",
+			} {
+				val := backendReq.Header.Get(key)
+				if val != test.expected {
+					t.Fatalf("%s %q, expected %q",
+						key, val, test.expected)
+				}
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package request
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,18 +13,12 @@ import (
 
 	"github.com/dropbox/godropbox/errors"
 	"github.com/gin-gonic/gin"
+	"github.com/pritunl/pritunl-web/capture"
 	"github.com/pritunl/pritunl-web/constants"
 	"github.com/pritunl/pritunl-web/errortypes"
 	"github.com/pritunl/tools/logger"
 )
 
-var client = &http.Client{
-	CheckRedirect: func(req *http.Request, via []*http.Request) error {
-		return http.ErrUseLastResponse
-	},
-	Timeout: 2 * time.Minute,
-}
-
 type Request struct {
 	Method   string
 	Path     string
@@ -34,9 +29,11 @@ type Request struct {
 }
 
 func (r *Request) Send(c *gin.Context) (resp *http.Response, err error) {
-	reqUrl := "http://" + constants.InternalHost + r.Path
+	reqUrl := internalUrl(r.Path)
 
 	var body io.Reader
+	var clientBody []byte
+	var backendBody []byte
 
 	if r.Json != nil {
 		if c.ContentType() != "application/json" {
@@ -47,6 +44,10 @@ func (r *Request) Send(c *gin.Context) (resp *http.Response, err error) {
 			return
 		}
 
+		if capture.Enabled() {
+			clientBody = captureClientBody(c)
+		}
+
 		err = c.BindJSON(r.Json)
 		if err != nil {
 			return
@@ -64,6 +65,7 @@ func (r *Request) Send(c *gin.Context) (resp *http.Response, err error) {
 			return
 		}
 
+		backendBody = data
 		body = bytes.NewBuffer(data)
 	}
 
@@ -102,18 +104,19 @@ func (r *Request) Send(c *gin.Context) (resp *http.Response, err error) {
 		"PR-Validated",
 		strconv.FormatBool(c.MustGet("validated").(bool)),
 	)
-	req.Header.Set("PR-Forwarded-Header",
-		c.Request.Header.Get(constants.ReverseProxyHeader))
 	req.Header.Set("PR-Forwarded-Url", forwardUrl.String())
-	req.Header.Set("PR-Forwarded-For",
-		parseRemoteAddr(c.Request.RemoteAddr))
+	setForwardedHeaders(req, ClientIp(c))
+	setClientCertHeaders(req, c.Request)
 
 	copyHeader(req, c.Request, "Auth-Token")
 	copyHeader(req, c.Request, "Auth-Timestamp")
 	copyHeader(req, c.Request, "Auth-Nonce")
 	copyHeader(req, c.Request, "Auth-Signature")
 
-	copyHeader(req, c.Request, "Cookie")
+	cookies := backendCookies(c.Request)
+	if cookies != "" {
+		req.Header.Set("Cookie", cookies)
+	}
 	copyHeader(req, c.Request, "Csrf-Token")
 
 	if r.Headers != nil {
@@ -132,12 +135,17 @@ func (r *Request) Send(c *gin.Context) (resp *http.Response, err error) {
 			errors.Wrap(err, "request: Request failed"),
 		}
 		logger.WithFields(logger.Fields{
-			"error": err,
+			"client_ip": ClientIp(c),
+			"error":     err,
 		}).Error("request: Request error")
 		c.AbortWithError(502, err)
 		return
 	}
 
+	if capture.Enabled() {
+		captureSend(c, clientBody, req, backendBody, resp)
+	}
+
 	return
 }
 
@@ -148,6 +156,16 @@ func (r *Request) Do(c *gin.Context) {
 	}
 	defer resp.Body.Close()
 
+	Write(c, resp)
+}
+
+func Write(c *gin.Context, resp *http.Response) {
+	filterResponse(c.Request, resp.Header)
+	if edgeHeaders, ok := c.Get("edge_headers"); ok {
+		for key := range edgeHeaders.(map[string]bool) {
+			resp.Header.Del(key)
+		}
+	}
 	copyHeaders(c.Writer.Header(), resp.Header)
 	c.Writer.Header().Del("Server")
 	c.Writer.WriteHeader(resp.StatusCode)
@@ -159,7 +177,7 @@ func WriteError(w http.ResponseWriter, code int, err error) {
 }
 
 func DoCheck(w http.ResponseWriter, r *http.Request) {
-	reqUrl := "http://" + constants.InternalHost + "/check"
+	reqUrl := internalUrl("/check")
 
 	req, err := http.NewRequest("GET", reqUrl, nil)
 	if err != nil {
@@ -178,10 +196,8 @@ func DoCheck(w http.ResponseWriter, r *http.Request) {
 		Host:   r.Host,
 	}
 
-	req.Header.Set("PR-Forwarded-Header",
-		r.Header.Get(constants.ReverseProxyHeader))
 	req.Header.Set("PR-Forwarded-Url", forwardUrl.String())
-	req.Header.Set("PR-Forwarded-For", parseRemoteAddr(r.RemoteAddr))
+	setForwardedHeaders(req, ParseClientIp(r))
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -196,8 +212,66 @@ func DoCheck(w http.ResponseWriter, r *http.Request) {
 	}
 	defer resp.Body.Close()
 
+	filterResponse(r, resp.Header)
+	copyHeaders(w.Header(), resp.Header)
+	w.Header().Del("Server")
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+func DoAcmeChallenge(w http.ResponseWriter, r *http.Request, token string) {
+	reqUrl := internalUrl("/.well-known/acme-challenge/" + token)
+
+	resp, err := client.Get(reqUrl)
+	if err != nil {
+		err = errortypes.RequestError{
+			errors.Wrap(err, "request: Request failed"),
+		}
+		logger.WithFields(logger.Fields{
+			"error": err,
+		}).Error("request: Acme challenge request error")
+		WriteError(w, 500, err)
+		return
+	}
+	defer resp.Body.Close()
+
 	copyHeaders(w.Header(), resp.Header)
 	w.Header().Del("Server")
 	w.WriteHeader(resp.StatusCode)
 	io.Copy(w, resp.Body)
 }
+
+func Check(timeout time.Duration) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(
+		ctx, "GET", internalUrl("/check"), nil)
+	if err != nil {
+		err = &errortypes.RequestError{
+			errors.Wrap(err, "request: Create request failed"),
+		}
+		return
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		err = &errortypes.RequestError{
+			errors.Wrap(err, "request: Request failed"),
+		}
+		return
+	}
+	defer resp.Body.Close()
+
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != 200 {
+		err = &errortypes.RequestError{
+			errors.Newf("request: Check returned status %d",
+				resp.StatusCode),
+		}
+		return
+	}
+
+	return
+}
@@ -0,0 +1,137 @@
+package request
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-web/constants"
+	"github.com/pritunl/pritunl-web/errortypes"
+	"github.com/pritunl/tools/logger"
+)
+
+var (
+	internalCert     *tls.Certificate
+	internalPool     *x509.CertPool
+	internalCertLock = sync.RWMutex{}
+)
+
+func loadInternalTls() (err error) {
+	var pool *x509.CertPool
+	var cert *tls.Certificate
+
+	if constants.Internal.CaPath != "" {
+		caByt, e := os.ReadFile(constants.Internal.CaPath)
+		if e != nil {
+			err = &errortypes.ReadError{
+				errors.Wrap(e, "request: Failed to read internal CA"),
+			}
+			return
+		}
+
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caByt) {
+			err = &errortypes.ParseError{
+				errors.New("request: No certificates found in internal CA"),
+			}
+			return
+		}
+	}
+
+	if constants.Internal.CertPath != "" || constants.Internal.KeyPath != "" {
+		keyPair, e := tls.LoadX509KeyPair(
+			constants.Internal.CertPath, constants.Internal.KeyPath)
+		if e != nil {
+			err = &errortypes.ParseError{
+				errors.Wrap(e, "request: Failed to load internal client cert"),
+			}
+			return
+		}
+		cert = &keyPair
+	}
+
+	internalCertLock.Lock()
+	internalPool = pool
+	internalCert = cert
+	internalCertLock.Unlock()
+
+	return
+}
+
+func newTlsConfig(serverName string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+		// Verification is done in VerifyConnection against the pinned
+		// pool to allow the CA to be reloaded
+		InsecureSkipVerify: true,
+		GetClientCertificate: func(info *tls.CertificateRequestInfo) (
+			*tls.Certificate, error) {
+
+			internalCertLock.RLock()
+			cert := internalCert
+			internalCertLock.RUnlock()
+
+			if cert == nil {
+				return &tls.Certificate{}, nil
+			}
+			return cert, nil
+		},
+		VerifyConnection: func(state tls.ConnectionState) (err error) {
+			if len(state.PeerCertificates) == 0 {
+				err = &errortypes.RequestError{
+					errors.New("request: Internal server sent no certificate"),
+				}
+				return
+			}
+
+			internalCertLock.RLock()
+			pool := internalPool
+			internalCertLock.RUnlock()
+
+			opts := x509.VerifyOptions{
+				Roots:         pool,
+				DNSName:       serverName,
+				Intermediates: x509.NewCertPool(),
+			}
+			for _, cert := range state.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(cert)
+			}
+
+			_, err = state.PeerCertificates[0].Verify(opts)
+			if err != nil {
+				err = &errortypes.RequestError{
+					errors.Wrap(err, "request: Internal server verify failed"),
+				}
+				return
+			}
+
+			return
+		},
+	}
+}
+
+func ReloadTls() (err error) {
+	if internalScheme != "https" {
+		return
+	}
+
+	err = loadInternalTls()
+	if err != nil {
+		return
+	}
+
+	if transport, ok := client.Transport.(*http.Transport); ok {
+		transport.CloseIdleConnections()
+	}
+
+	logger.WithFields(logger.Fields{
+		"ca_path":   constants.Internal.CaPath,
+		"cert_path": constants.Internal.CertPath,
+	}).Info("request: Reloaded internal TLS certificates")
+
+	return
+}
@@ -2,6 +2,7 @@ package request
 
 import (
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
@@ -28,9 +29,13 @@ func copyHeaders(dst, src http.Header) {
 }
 
 func parseRemoteAddr(remoteAddr string) (addr string) {
-	addr = remoteAddr[:strings.LastIndex(remoteAddr, ":")]
-	addr = strings.Replace(addr, "[", "", 1)
-	addr = strings.Replace(addr, "]", "", 1)
+	addr, _, err := net.SplitHostPort(remoteAddr)
+	if err == nil {
+		return
+	}
+
+	addr = strings.TrimPrefix(remoteAddr, "[")
+	addr = strings.TrimSuffix(addr, "]")
 	return
 }
 
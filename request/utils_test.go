@@ -0,0 +1,45 @@
+package request
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+func FuzzParseRemoteAddr(f *testing.F) {
+	f.Add("198.51.100.7:43210")
+	f.Add("[2001:db8::1]:443")
+	f.Add("[::1]")
+	f.Add("198.51.100.7")
+	f.Add("@")
+	f.Add("")
+	f.Add(":")
+
+	f.Fuzz(func(t *testing.T, remoteAddr string) {
+		addr := parseRemoteAddr(remoteAddr)
+
+		if !strings.Contains(remoteAddr, addr) {
+			t.Fatalf("parsed %q not in %q", addr, remoteAddr)
+		}
+
+		host, _, err := net.SplitHostPort(remoteAddr)
+		if err != nil {
+			return
+		}
+
+		if addr != host {
+			t.Fatalf("parsed %q from %q, expected %q",
+				addr, remoteAddr, host)
+		}
+
+		if strings.ContainsAny(addr, "[]") {
+			t.Fatalf("parsed %q from %q with brackets", addr, remoteAddr)
+		}
+
+		if !strings.HasPrefix(remoteAddr, "[") &&
+			strings.Contains(addr, ":") {
+
+			t.Fatalf("parsed %q from %q with port", addr, remoteAddr)
+		}
+	})
+}
@@ -0,0 +1,458 @@
+package main
+
+import (
+	"encoding/base64"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-web/config"
+	"github.com/pritunl/pritunl-web/constants"
+	"github.com/pritunl/pritunl-web/errortypes"
+	"github.com/pritunl/pritunl-web/policy"
+	"github.com/pritunl/pritunl-web/privdrop"
+	"github.com/pritunl/pritunl-web/utils"
+	"github.com/sirupsen/logrus"
+)
+
+func checkPositive(name string, n int) (err error) {
+	if n <= 0 {
+		err = &errortypes.ParseError{
+			errors.Newf("main: Invalid %s '%d'", name, n),
+		}
+		return
+	}
+	return
+}
+
+func parseHeaderSet(vals []string) map[string]bool {
+	headers := map[string]bool{}
+	for _, key := range vals {
+		headers[http.CanonicalHeaderKey(key)] = true
+	}
+	return headers
+}
+
+func parseSameSite(vals []string) (modes map[string]string, err error) {
+	modes = map[string]string{}
+
+	for _, item := range vals {
+		itemSpl := strings.SplitN(item, "=", 2)
+		if len(itemSpl) != 2 {
+			err = &errortypes.ParseError{
+				errors.Newf("main: Invalid cookie SameSite '%s'", item),
+			}
+			return
+		}
+
+		mode := strings.ToLower(strings.TrimSpace(itemSpl[1]))
+		switch mode {
+		case "strict", "lax", "none":
+		default:
+			err = &errortypes.ParseError{
+				errors.Newf("main: Invalid cookie SameSite mode '%s'", mode),
+			}
+			return
+		}
+
+		modes[strings.TrimSpace(itemSpl[0])] = mode
+	}
+
+	return
+}
+
+func loadPolicies(policies map[string]config.PolicyConfig) (err error) {
+	for name := range policies {
+		known := false
+		for _, class := range policy.Classes {
+			if name == string(class) {
+				known = true
+				break
+			}
+		}
+
+		if !known {
+			err = &errortypes.ParseError{
+				errors.Newf("main: Unknown policy class '%s'", name),
+			}
+			return
+		}
+	}
+
+	for _, class := range policy.Classes {
+		polConf := policies[string(class)]
+		allowStr := strings.Join(polConf.Allow, ",")
+		denyStr := strings.Join(polConf.Deny, ",")
+
+		pol := &policy.Policy{}
+
+		pol.Allow, err = utils.ParseCidrs(allowStr)
+		if err != nil {
+			return
+		}
+
+		pol.Deny, err = utils.ParseCidrs(denyStr)
+		if err != nil {
+			return
+		}
+
+		if !pol.Empty() {
+			logrus.WithFields(logrus.Fields{
+				"class": class,
+				"allow": allowStr,
+				"deny":  denyStr,
+			}).Info("main: Loaded network policy")
+		}
+
+		policy.Set(class, pol)
+	}
+
+	return
+}
+
+var webSecretSize int
+
+func parseWebSecret(val string) (secret *[32]byte, err error) {
+	webSecretByt, err := base64.StdEncoding.DecodeString(val)
+	if err != nil {
+		err = &errortypes.ParseError{
+			errors.Wrap(err, "main: Failed to decode web secret"),
+		}
+		return
+	}
+
+	webSecretSize = len(webSecretByt)
+	if webSecretSize != 32 {
+		logrus.WithFields(logrus.Fields{
+			"size": webSecretSize,
+		}).Warn("main: Web secret is not 32 bytes, padding or " +
+			"truncating is deprecated and will be removed")
+	}
+
+	secret = &[32]byte{}
+	copy(secret[:], webSecretByt)
+
+	return
+}
+
+func applySecrets(conf *config.Config) (err error) {
+	var webSecret *[32]byte
+	if conf.WebSecret != "" {
+		webSecret, err = parseWebSecret(conf.WebSecret)
+		if err != nil {
+			return
+		}
+	}
+
+	sslExtraCerts := []string{}
+	sslExtraKeys := []string{}
+	for _, cert := range conf.SslCerts {
+		if cert.Cert == "" || cert.Key == "" {
+			err = &errortypes.ParseError{
+				errors.New("main: Extra certificate missing cert or key"),
+			}
+			return
+		}
+
+		sslExtraCerts = append(sslExtraCerts, cert.Cert)
+		sslExtraKeys = append(sslExtraKeys, cert.Key)
+	}
+
+	constants.SslCert = conf.SslCert
+	constants.SslKey = conf.SslKey
+	constants.Certs.ExtraCerts = sslExtraCerts
+	constants.Certs.ExtraKeys = sslExtraKeys
+	constants.WebSecret.Store(webSecret)
+
+	return
+}
+
+func reloadSecrets(conf *config.Config) (err error) {
+	err = conf.ReloadSecrets()
+	if err != nil {
+		return
+	}
+
+	if conf.WebSecret == "" && constants.WebSecret.Load() != nil {
+		err = &errortypes.ParseError{
+			errors.New("main: Web secret missing on reload"),
+		}
+		return
+	}
+
+	err = applySecrets(conf)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func applyConfig(conf *config.Config) (err error) {
+	constants.ReverseProxyHeader = conf.ReverseProxyHeader
+	constants.ReverseProxyProtoHeader = conf.ReverseProxyProtoHeader
+	constants.RedirectServer = conf.RedirectServer
+	constants.BindHost = conf.BindHost
+	constants.BindPort = ""
+	if conf.BindPort != 0 {
+		constants.BindPort = strconv.Itoa(conf.BindPort)
+	}
+	constants.MetricsAddress = conf.MetricsAddress
+	constants.InternalHost = conf.InternalAddress
+	constants.WebStrict = conf.WebStrict
+	constants.CapturePath = conf.CapturePath
+	constants.PublicHosts = conf.PublicHosts
+
+	constants.Internal = constants.InternalConfig{
+		ServerName: conf.InternalServerName,
+		CaPath:     conf.InternalCaPath,
+		CertPath:   conf.InternalCertPath,
+		KeyPath:    conf.InternalKeyPath,
+	}
+	constants.Certs = constants.CertsConfig{
+		Dir:           conf.SslCertDir,
+		DefaultName:   conf.SslDefaultName,
+		OcspStapling:  conf.SslOcspStapling,
+		OcspResponder: conf.SslOcspResponder,
+	}
+	constants.Tls = constants.TlsConfig{
+		Profile:      conf.TlsProfile,
+		MinVersion:   conf.TlsMinVersion,
+		MaxVersion:   conf.TlsMaxVersion,
+		CipherSuites: strings.Join(conf.TlsCipherSuites, ","),
+		Curves:       strings.Join(conf.TlsCurves, ","),
+		Alpn:         strings.Join(conf.TlsAlpn, ","),
+	}
+	constants.Headers = constants.HeadersConfig{
+		HstsMaxAge:            conf.HstsMaxAge,
+		HstsIncludeSubdomains: conf.HstsIncludeSubdomains,
+		HstsPreload:           conf.HstsPreload,
+		ContentSecurityPolicy: conf.ContentSecurityPolicy,
+		FrameOptions:          conf.FrameOptions,
+		ReferrerPolicy:        conf.ReferrerPolicy,
+		PermissionsPolicy:     conf.PermissionsPolicy,
+		Override:              conf.SecurityHeadersOverride,
+		CspReport:             conf.CspReport,
+		ResponseAllow:         parseHeaderSet(conf.ResponseHeaderAllow),
+		ResponseDeny:          parseHeaderSet(conf.ResponseHeaderDeny),
+	}
+	constants.Cookies = constants.CookiesConfig{
+		HttpOnly:   conf.CookieHttpOnly,
+		HostPrefix: conf.CookieHostPrefix,
+	}
+	constants.Acme = constants.AcmeConfig{
+		Domains:   conf.AcmeDomains,
+		Email:     conf.AcmeEmail,
+		Directory: conf.AcmeDirectory,
+		CaPath:    conf.AcmeCaPath,
+		CachePath: conf.AcmeCachePath,
+	}
+	constants.AdminClient = constants.AdminClientConfig{
+		Mode:   conf.AdminClientCert,
+		CaPath: conf.AdminClientCaPath,
+		Hosts:  conf.AdminClientCertHosts,
+	}
+	constants.Session = constants.SessionConfig{
+		Csrf:         conf.WebCsrf,
+		Renew:        conf.WebSessionRenew,
+		Ttl:          conf.WebSessionTtl,
+		MaxAge:       conf.WebSessionMaxAge,
+		TokenBinding: conf.WebTokenBinding,
+	}
+	constants.Api = constants.ApiConfig{
+		AuthWindow:     conf.ApiAuthWindow,
+		NonceCacheSize: conf.ApiNonceCacheSize,
+		SecretsPath:    conf.ApiSecretsPath,
+	}
+	constants.Run = constants.RunConfig{
+		User:       conf.RunAsUser,
+		Group:      conf.RunAsGroup,
+		NoNewPrivs: conf.RunNoNewPrivs,
+		Landlock:   conf.RunLandlock,
+	}
+
+	err = applySecrets(conf)
+	if err != nil {
+		return
+	}
+
+	constants.Ssl = (constants.SslCert != "" && constants.SslKey != "") ||
+		len(constants.Certs.ExtraCerts) > 0 || constants.Certs.Dir != "" ||
+		len(constants.Acme.Domains) > 0
+	if constants.Ssl {
+		constants.Scheme = "https"
+	} else {
+		constants.Scheme = "http"
+	}
+
+	constants.Cookies.SameSite, err = parseSameSite(conf.CookieSameSite)
+	if err != nil {
+		return
+	}
+
+	constants.Proxy.Trusted, err = utils.ParseCidrs(
+		strings.Join(conf.TrustedProxies, ","))
+	if err != nil {
+		return
+	}
+
+	constants.Proxy.ProtocolTrusted, err = utils.ParseCidrs(
+		strings.Join(conf.ProxyProtocolTrusted, ","))
+	if err != nil {
+		return
+	}
+
+	switch constants.AdminClient.Mode {
+	case "", "optional":
+	case "required":
+		if !constants.Ssl || constants.AdminClient.CaPath == "" {
+			err = &errortypes.ParseError{
+				errors.New("main: Admin client certificates require " +
+					"SSL and a client CA"),
+			}
+			return
+		}
+	default:
+		err = &errortypes.ParseError{
+			errors.Newf("main: Invalid admin client cert mode '%s'",
+				constants.AdminClient.Mode),
+		}
+		return
+	}
+
+	switch constants.Session.Csrf {
+	case "", "origin", "token":
+	default:
+		err = &errortypes.ParseError{
+			errors.Newf("main: Invalid CSRF mode '%s'",
+				constants.Session.Csrf),
+		}
+		return
+	}
+
+	switch constants.Session.TokenBinding {
+	case "", "off", "audit", "enforce":
+	default:
+		err = &errortypes.ParseError{
+			errors.Newf("main: Invalid token binding mode '%s'",
+				constants.Session.TokenBinding),
+		}
+		return
+	}
+
+	if constants.Session.Renew < 0 || constants.Session.Renew >= 1 {
+		err = &errortypes.ParseError{
+			errors.Newf("main: Invalid session renew fraction '%g'",
+				constants.Session.Renew),
+		}
+		return
+	}
+
+	if constants.Headers.HstsMaxAge < 0 {
+		err = &errortypes.ParseError{
+			errors.Newf("main: Invalid HSTS max age '%d'",
+				constants.Headers.HstsMaxAge),
+		}
+		return
+	}
+
+	err = checkPositive("session ttl", constants.Session.Ttl)
+	if err != nil {
+		return
+	}
+
+	err = checkPositive("session max age", constants.Session.MaxAge)
+	if err != nil {
+		return
+	}
+
+	err = checkPositive("API auth window", constants.Api.AuthWindow)
+	if err != nil {
+		return
+	}
+
+	err = checkPositive("API nonce cache size", constants.Api.NonceCacheSize)
+	if err != nil {
+		return
+	}
+
+	err = loadPolicies(conf.Policies)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func privilegePaths(conf *config.Config, configPath string) (
+	readPaths, writePaths []string) {
+
+	readPaths = []string{
+		"/etc/ssl",
+		"/etc/pki",
+		"/etc/hosts",
+		"/etc/resolv.conf",
+		"/etc/nsswitch.conf",
+		"/etc/localtime",
+		"/usr/share/zoneinfo",
+	}
+
+	for _, path := range []string{
+		configPath,
+		os.Getenv("CREDENTIALS_DIRECTORY"),
+		conf.SslCertFile,
+		conf.SslKeyFile,
+		conf.WebSecretFile,
+		constants.Certs.Dir,
+		constants.Internal.CaPath,
+		constants.Internal.CertPath,
+		constants.Internal.KeyPath,
+		constants.Api.SecretsPath,
+	} {
+		if path != "" {
+			readPaths = append(readPaths, path)
+		}
+	}
+
+	for _, cert := range conf.SslCerts {
+		if cert.CertFile != "" {
+			readPaths = append(readPaths, cert.CertFile)
+		}
+		if cert.KeyFile != "" {
+			readPaths = append(readPaths, cert.KeyFile)
+		}
+	}
+
+	if len(constants.Acme.Domains) > 0 && constants.Acme.CachePath != "" {
+		writePaths = append(writePaths, constants.Acme.CachePath)
+	}
+	if constants.CapturePath != "" {
+		writePaths = append(writePaths,
+			filepath.Dir(constants.CapturePath))
+	}
+
+	return
+}
+
+func dropPrivileges(conf *config.Config, configPath string) (err error) {
+	opts := &privdrop.Options{
+		User:       constants.Run.User,
+		Group:      constants.Run.Group,
+		NoNewPrivs: constants.Run.NoNewPrivs,
+		Landlock:   constants.Run.Landlock,
+	}
+
+	if opts.Landlock {
+		opts.ReadPaths, opts.WritePaths = privilegePaths(conf, configPath)
+	}
+
+	err = privdrop.Drop(opts)
+	if err != nil {
+		return
+	}
+
+	return
+}
@@ -0,0 +1,94 @@
+package systemd
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-web/errortypes"
+)
+
+var listenFdsStart = 3
+
+var listeners = map[string]net.Listener{}
+
+func loadListeners() (err error) {
+	pidStr := os.Getenv("LISTEN_PID")
+	fdsStr := os.Getenv("LISTEN_FDS")
+	namesStr := os.Getenv("LISTEN_FDNAMES")
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	if fdsStr == "" {
+		return
+	}
+
+	if pidStr != "" {
+		pid, e := strconv.Atoi(pidStr)
+		if e != nil || pid != os.Getpid() {
+			return
+		}
+	}
+
+	count, err := strconv.Atoi(fdsStr)
+	if err != nil || count < 0 {
+		err = &errortypes.ParseError{
+			errors.Newf("systemd: Invalid LISTEN_FDS '%s'", fdsStr),
+		}
+		return
+	}
+
+	names := []string{}
+	if namesStr != "" {
+		names = strings.Split(namesStr, ":")
+	}
+
+	for i := 0; i < count; i++ {
+		fd := listenFdsStart + i
+		syscall.CloseOnExec(fd)
+
+		name := ""
+		if i < len(names) {
+			name = names[i]
+		}
+		if name == "" || name == "unknown" {
+			switch i {
+			case 0:
+				name = "main"
+			case 1:
+				name = "redirect"
+			default:
+				name = "fd" + strconv.Itoa(fd)
+			}
+		}
+
+		file := os.NewFile(uintptr(fd), name)
+		listener, e := net.FileListener(file)
+		file.Close()
+		if e != nil {
+			err = &errortypes.ParseError{
+				errors.Wrapf(e, "systemd: Failed to use socket '%s'", name),
+			}
+			return
+		}
+
+		listeners[name] = listener
+	}
+
+	return
+}
+
+func Listener(name string) net.Listener {
+	listener := listeners[name]
+	delete(listeners, name)
+	return listener
+}
+
+func HasListener(name string) bool {
+	_, ok := listeners[name]
+	return ok
+}
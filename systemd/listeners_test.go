@@ -0,0 +1,109 @@
+package systemd
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+)
+
+func listenFd(t *testing.T) (addr string, fd int) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	file, err := listener.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	fd, err = syscall.Dup(int(file.Fd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return listener.Addr().String(), fd
+}
+
+func TestLoadListeners(t *testing.T) {
+	addr, fd := listenFd(t)
+
+	listenFdsStart = fd
+	t.Cleanup(func() {
+		listenFdsStart = 3
+		for name, listener := range listeners {
+			listener.Close()
+			delete(listeners, name)
+		}
+	})
+
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "1")
+	t.Setenv("LISTEN_FDNAMES", "main")
+
+	err := loadListeners()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if os.Getenv("LISTEN_FDS") != "" {
+		t.Fatal("LISTEN_FDS not unset")
+	}
+
+	if !HasListener("main") {
+		t.Fatal("main listener not loaded")
+	}
+
+	listener := Listener("main")
+	defer listener.Close()
+
+	if HasListener("main") {
+		t.Fatal("main listener not removed")
+	}
+
+	if listener.Addr().String() != addr {
+		t.Fatalf("listener address %s, expected %s",
+			listener.Addr(), addr)
+	}
+
+	go func() {
+		conn, e := net.Dial("tcp", addr)
+		if e == nil {
+			conn.Close()
+		}
+	}()
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+}
+
+func TestLoadListenersPid(t *testing.T) {
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+
+	err := loadListeners()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(listeners) != 0 {
+		t.Fatal("listener loaded for another pid")
+	}
+}
+
+func TestLoadListenersInvalid(t *testing.T) {
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "-1")
+
+	err := loadListeners()
+	if err == nil {
+		t.Fatal("invalid LISTEN_FDS accepted")
+	}
+}
@@ -0,0 +1,101 @@
+package systemd
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-web/errortypes"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	notifySocket     = ""
+	watchdogInterval time.Duration
+)
+
+func loadNotify() {
+	notifySocket = os.Getenv("NOTIFY_SOCKET")
+	usecStr := os.Getenv("WATCHDOG_USEC")
+	pidStr := os.Getenv("WATCHDOG_PID")
+	os.Unsetenv("NOTIFY_SOCKET")
+	os.Unsetenv("WATCHDOG_USEC")
+	os.Unsetenv("WATCHDOG_PID")
+
+	if pidStr != "" {
+		pid, err := strconv.Atoi(pidStr)
+		if err != nil || pid != os.Getpid() {
+			return
+		}
+	}
+
+	usec, err := strconv.ParseInt(usecStr, 10, 64)
+	if err == nil && usec > 0 {
+		watchdogInterval = time.Duration(usec) * time.Microsecond
+	}
+}
+
+func Notify(state string) (err error) {
+	if notifySocket == "" {
+		return
+	}
+
+	addr := &net.UnixAddr{
+		Name: notifySocket,
+		Net:  "unixgram",
+	}
+	if addr.Name[0] == '@' {
+		addr.Name = "\x00" + addr.Name[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, addr)
+	if err != nil {
+		err = &errortypes.WriteError{
+			errors.Wrap(err, "systemd: Failed to connect notify socket"),
+		}
+		return
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+	if err != nil {
+		err = &errortypes.WriteError{
+			errors.Wrap(err, "systemd: Failed to write notify socket"),
+		}
+		return
+	}
+
+	return
+}
+
+func WatchdogInterval() time.Duration {
+	return watchdogInterval
+}
+
+func Watchdog(check func() error) {
+	if watchdogInterval == 0 || notifySocket == "" {
+		return
+	}
+
+	ticker := time.NewTicker(watchdogInterval / 2)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		err := check()
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"error": err,
+			}).Warn("systemd: Watchdog check failed")
+			continue
+		}
+
+		err = Notify("WATCHDOG=1")
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("systemd: Failed to send watchdog ping")
+		}
+	}
+}
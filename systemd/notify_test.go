@@ -0,0 +1,113 @@
+package systemd
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func notifyListen(t *testing.T) *net.UnixConn {
+	path := filepath.Join(t.TempDir(), "notify.sock")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{
+		Name: path,
+		Net:  "unixgram",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	t.Setenv("NOTIFY_SOCKET", path)
+	t.Cleanup(func() {
+		notifySocket = ""
+		watchdogInterval = 0
+	})
+
+	return conn
+}
+
+func notifyRead(t *testing.T, conn *net.UnixConn) string {
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	buf := make([]byte, 256)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(buf[:n])
+}
+
+func TestNotify(t *testing.T) {
+	err := Notify("READY=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn := notifyListen(t)
+	loadNotify()
+
+	if os.Getenv("NOTIFY_SOCKET") != "" {
+		t.Fatal("NOTIFY_SOCKET not unset")
+	}
+
+	err = Notify("READY=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if state := notifyRead(t, conn); state != "READY=1" {
+		t.Fatalf("state %q, expected READY=1", state)
+	}
+}
+
+func TestWatchdogPid(t *testing.T) {
+	notifyListen(t)
+	t.Setenv("WATCHDOG_USEC", "20000")
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()+1))
+	loadNotify()
+
+	if WatchdogInterval() != 0 {
+		t.Fatal("watchdog enabled for another pid")
+	}
+}
+
+func TestWatchdog(t *testing.T) {
+	conn := notifyListen(t)
+	t.Setenv("WATCHDOG_USEC", "20000")
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	loadNotify()
+
+	if WatchdogInterval() != 20*time.Millisecond {
+		t.Fatalf("watchdog interval %s, expected 20ms", WatchdogInterval())
+	}
+
+	checks := atomic.Int32{}
+	done := atomic.Bool{}
+	t.Cleanup(func() {
+		done.Store(true)
+	})
+
+	go Watchdog(func() error {
+		if checks.Add(1) == 1 || done.Load() {
+			return errors.New("backend unavailable")
+		}
+		return nil
+	})
+
+	if state := notifyRead(t, conn); state != "WATCHDOG=1" {
+		t.Fatalf("state %q, expected WATCHDOG=1", state)
+	}
+
+	if checks.Load() < 2 {
+		t.Fatal("watchdog pinged after failed check")
+	}
+}
@@ -0,0 +1,12 @@
+package systemd
+
+func Init() (err error) {
+	loadNotify()
+
+	err = loadListeners()
+	if err != nil {
+		return
+	}
+
+	return
+}
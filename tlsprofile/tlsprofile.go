@@ -0,0 +1,356 @@
+package tlsprofile
+
+import (
+	"crypto/fips140"
+	"crypto/tls"
+	"strings"
+
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-web/errortypes"
+	"github.com/sirupsen/logrus"
+)
+
+type Profile struct {
+	Name         string
+	MinVersion   uint16
+	MaxVersion   uint16
+	CipherSuites []uint16
+	Curves       []tls.CurveID
+	NextProtos   []string
+	Fips         bool
+}
+
+var profiles = map[string]Profile{
+	"modern": {
+		MinVersion: tls.VersionTLS13,
+		MaxVersion: tls.VersionTLS13,
+		Curves: []tls.CurveID{
+			tls.X25519MLKEM768,
+			tls.X25519,
+			tls.CurveP256,
+			tls.CurveP384,
+		},
+		NextProtos: []string{"h2", "http/1.1"},
+	},
+	"intermediate": {
+		MinVersion: tls.VersionTLS12,
+		MaxVersion: tls.VersionTLS13,
+		CipherSuites: []uint16{
+			tls.TLS_AES_128_GCM_SHA256,                        // 0x1301
+			tls.TLS_AES_256_GCM_SHA384,                        // 0x1302
+			tls.TLS_CHACHA20_POLY1305_SHA256,                  // 0x1303
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,       // 0xc02b
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,         // 0xc02f
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,       // 0xc02c
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,         // 0xc030
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256, // 0xcca9
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,   // 0xcca8
+		},
+		NextProtos: []string{"h2", "http/1.1"},
+	},
+	"fips": {
+		MinVersion: tls.VersionTLS12,
+		MaxVersion: tls.VersionTLS12,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, // 0xc02b
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,   // 0xc02f
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384, // 0xc02c
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,   // 0xc030
+		},
+		Curves: []tls.CurveID{
+			tls.CurveP256,
+			tls.CurveP384,
+		},
+		NextProtos: []string{"h2", "http/1.1"},
+		Fips:       true,
+	},
+}
+
+var versions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+var curves = map[string]tls.CurveID{
+	"X25519":         tls.X25519,
+	"X25519MLKEM768": tls.X25519MLKEM768,
+	"P256":           tls.CurveP256,
+	"P384":           tls.CurveP384,
+	"P521":           tls.CurveP521,
+}
+
+var fipsCurves = map[tls.CurveID]bool{
+	tls.CurveP256: true,
+	tls.CurveP384: true,
+	tls.CurveP521: true,
+}
+
+func splitList(val string) (items []string) {
+	for _, item := range strings.Split(val, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return
+}
+
+func parseVersion(val string) (version uint16, err error) {
+	version, ok := versions[strings.TrimPrefix(val, "TLS")]
+	if !ok {
+		err = &errortypes.ParseError{
+			errors.Newf("tlsprofile: Unknown TLS version '%s'", val),
+		}
+		return
+	}
+	return
+}
+
+func cipherSuite(name string) (suite *tls.CipherSuite, insecure bool) {
+	for _, s := range tls.CipherSuites() {
+		if s.Name == name {
+			return s, false
+		}
+	}
+	for _, s := range tls.InsecureCipherSuites() {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+func cipherSuiteById(id uint16) *tls.CipherSuite {
+	for _, s := range tls.CipherSuites() {
+		if s.ID == id {
+			return s
+		}
+	}
+	for _, s := range tls.InsecureCipherSuites() {
+		if s.ID == id {
+			return s
+		}
+	}
+	return nil
+}
+
+func supportsRange(suite *tls.CipherSuite, minVer, maxVer uint16) bool {
+	for _, ver := range suite.SupportedVersions {
+		if ver >= minVer && ver <= maxVer {
+			return true
+		}
+	}
+	return false
+}
+
+func Build(name, minVersion, maxVersion, cipherSuites, curveNames,
+	alpn string) (profile *Profile, err error) {
+
+	if name == "" {
+		name = "intermediate"
+	}
+
+	base, ok := profiles[strings.ToLower(name)]
+	if !ok {
+		err = &errortypes.ParseError{
+			errors.Newf("tlsprofile: Unknown TLS profile '%s'", name),
+		}
+		return
+	}
+	profile = &base
+	profile.Name = strings.ToLower(name)
+
+	if profile.Fips && fips140.Enabled() {
+		profile.MaxVersion = tls.VersionTLS13
+	}
+
+	if minVersion != "" {
+		profile.MinVersion, err = parseVersion(minVersion)
+		if err != nil {
+			return
+		}
+	}
+
+	if maxVersion != "" {
+		profile.MaxVersion, err = parseVersion(maxVersion)
+		if err != nil {
+			return
+		}
+	}
+
+	if cipherSuites != "" {
+		profile.CipherSuites = []uint16{}
+
+		for _, suiteName := range splitList(cipherSuites) {
+			suite, insecure := cipherSuite(suiteName)
+			if suite == nil {
+				err = &errortypes.ParseError{
+					errors.Newf("tlsprofile: Unknown cipher suite '%s'",
+						suiteName),
+				}
+				return
+			}
+
+			if insecure {
+				logrus.WithFields(logrus.Fields{
+					"cipher_suite": suiteName,
+				}).Warn("tlsprofile: Insecure cipher suite enabled")
+			}
+
+			profile.CipherSuites = append(profile.CipherSuites, suite.ID)
+		}
+	}
+
+	if curveNames != "" {
+		profile.Curves = []tls.CurveID{}
+
+		for _, curveName := range splitList(curveNames) {
+			curve, ok := curves[curveName]
+			if !ok {
+				err = &errortypes.ParseError{
+					errors.Newf("tlsprofile: Unknown curve '%s'", curveName),
+				}
+				return
+			}
+
+			profile.Curves = append(profile.Curves, curve)
+		}
+	}
+
+	if alpn != "" {
+		profile.NextProtos = splitList(alpn)
+	}
+
+	err = profile.validate(cipherSuites != "")
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (p *Profile) validate(customSuites bool) (err error) {
+	if p.MinVersion > p.MaxVersion {
+		err = &errortypes.ParseError{
+			errors.New("tlsprofile: Minimum TLS version is greater " +
+				"than maximum version"),
+		}
+		return
+	}
+
+	if customSuites && p.MinVersion >= tls.VersionTLS13 {
+		err = &errortypes.ParseError{
+			errors.New("tlsprofile: Cipher suites cannot be configured " +
+				"for TLS 1.3 only profiles"),
+		}
+		return
+	}
+
+	if p.MinVersion < tls.VersionTLS13 && p.CipherSuites != nil {
+		usable := false
+
+		for _, id := range p.CipherSuites {
+			suite := cipherSuiteById(id)
+			if supportsRange(suite, p.MinVersion, tls.VersionTLS12) {
+				usable = true
+				break
+			}
+		}
+
+		if !usable {
+			err = &errortypes.ParseError{
+				errors.New("tlsprofile: No cipher suites usable with " +
+					"the configured TLS versions"),
+			}
+			return
+		}
+	}
+
+	if p.Fips {
+		if p.MinVersion < tls.VersionTLS12 {
+			err = &errortypes.ParseError{
+				errors.New("tlsprofile: FIPS profile requires TLS 1.2 " +
+					"or higher"),
+			}
+			return
+		}
+
+		if p.MaxVersion >= tls.VersionTLS13 && !fips140.Enabled() {
+			err = &errortypes.ParseError{
+				errors.New("tlsprofile: FIPS profile requires " +
+					"GODEBUG=fips140=on to allow TLS 1.3"),
+			}
+			return
+		}
+
+		for _, id := range p.CipherSuites {
+			suite := cipherSuiteById(id)
+			if strings.Contains(suite.Name, "CHACHA20") ||
+				!strings.Contains(suite.Name, "GCM") {
+
+				err = &errortypes.ParseError{
+					errors.Newf("tlsprofile: Cipher suite '%s' not "+
+						"allowed in FIPS profile", suite.Name),
+				}
+				return
+			}
+		}
+
+		for _, curve := range p.Curves {
+			if !fipsCurves[curve] {
+				err = &errortypes.ParseError{
+					errors.Newf("tlsprofile: Curve '%s' not allowed "+
+						"in FIPS profile", curve),
+				}
+				return
+			}
+		}
+	}
+
+	hasHttp1 := false
+	for _, proto := range p.NextProtos {
+		if proto == "http/1.1" {
+			hasHttp1 = true
+		}
+	}
+	if !hasHttp1 {
+		err = &errortypes.ParseError{
+			errors.New("tlsprofile: ALPN protocols must include http/1.1"),
+		}
+		return
+	}
+
+	return
+}
+
+func (p *Profile) Apply(config *tls.Config) {
+	config.MinVersion = p.MinVersion
+	config.MaxVersion = p.MaxVersion
+	config.CipherSuites = p.CipherSuites
+	config.CurvePreferences = p.Curves
+	config.NextProtos = p.NextProtos
+}
+
+func (p *Profile) Log() {
+	suiteNames := []string{}
+	for _, id := range p.CipherSuites {
+		suiteNames = append(suiteNames, tls.CipherSuiteName(id))
+	}
+
+	curveNames := []string{}
+	for _, curve := range p.Curves {
+		curveNames = append(curveNames, curve.String())
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"profile":       p.Name,
+		"min_version":   tls.VersionName(p.MinVersion),
+		"max_version":   tls.VersionName(p.MaxVersion),
+		"cipher_suites": strings.Join(suiteNames, ","),
+		"curves":        strings.Join(curveNames, ","),
+		"alpn":          strings.Join(p.NextProtos, ","),
+		"fips140":       fips140.Enabled(),
+	}).Info("tlsprofile: Effective TLS configuration")
+}
@@ -0,0 +1,77 @@
+package tlsprofile
+
+import (
+	"crypto/fips140"
+	"crypto/tls"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name       string
+		profile    string
+		minVersion string
+		maxVersion string
+		suites     string
+		curves     string
+		alpn       string
+		fail       bool
+	}{
+		{"default", "", "", "", "", "", "", false},
+		{"modern", "modern", "", "", "", "", "", false},
+		{"unknown profile", "legacy", "", "", "", "", "", true},
+		{"unknown version", "", "1.4", "", "", "", "", true},
+		{"min above max", "", "1.3", "1.2", "", "", "", true},
+		{"tls prefix version", "", "TLS1.2", "TLS1.3", "", "", "", false},
+		{"unknown suite", "", "", "",
+			"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_UNKNOWN",
+			"", "", true},
+		{"unknown curve", "", "", "", "", "X25519,P999", "", true},
+		{"tls12 suites with tls13 only", "", "1.3", "1.3",
+			"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "", "", true},
+		{"tls12 suites with tls13 profile", "modern", "", "",
+			"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "", "", true},
+		{"no usable suites", "", "1.2", "1.3",
+			"TLS_AES_128_GCM_SHA256", "", "", true},
+		{"custom suites", "", "1.2", "1.3",
+			"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "", "", false},
+		{"missing http1", "", "", "", "", "", "h2", true},
+		{"fips chacha", "fips", "", "",
+			"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256", "", "", true},
+		{"fips curve", "fips", "", "", "", "X25519", "", true},
+		{"fips tls11", "fips", "1.1", "", "", "", "", true},
+	}
+
+	for _, test := range tests {
+		profile, err := Build(test.profile, test.minVersion,
+			test.maxVersion, test.suites, test.curves, test.alpn)
+		if test.fail && err == nil {
+			t.Errorf("%s: invalid profile accepted", test.name)
+		} else if !test.fail && err != nil {
+			t.Errorf("%s: %s", test.name, err)
+		} else if !test.fail && profile.MinVersion > profile.MaxVersion {
+			t.Errorf("%s: minimum version above maximum", test.name)
+		}
+	}
+}
+
+func TestBuildFips(t *testing.T) {
+	if fips140.Enabled() {
+		t.Skip("FIPS 140 mode enabled")
+	}
+
+	profile, err := Build("fips", "", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if profile.MaxVersion != tls.VersionTLS12 {
+		t.Fatalf("fips max version %s, expected TLS 1.2",
+			tls.VersionName(profile.MaxVersion))
+	}
+
+	_, err = Build("fips", "", "1.3", "", "", "")
+	if err == nil {
+		t.Fatal("fips profile with TLS 1.3 accepted without FIPS 140 mode")
+	}
+}
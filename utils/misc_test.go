@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func FuzzFilterStr(f *testing.F) {
+	f.Add("5f2a9c1e8b3d4a6f7e0c1b2a", 128)
+	f.Add("../../etc/passwd", 128)
+	f.Add("id<x>'1\"", 128)
+	f.Add("a#b?c%2e%2e/", 16)
+	f.Add("\xff\xfe日本", 3)
+	f.Add("", 0)
+
+	f.Fuzz(func(t *testing.T, s string, n int) {
+		if n < 0 {
+			n = -n
+		}
+		n %= 1024
+
+		out := FilterStr(s, n)
+
+		if len(out) > n {
+			t.Fatalf("output length %d exceeds %d", len(out), n)
+		}
+
+		if !utf8.ValidString(out) {
+			t.Fatalf("output %q is not valid utf8", out)
+		}
+
+		for _, c := range out {
+			if !safeChars.Contains(c) {
+				t.Fatalf("output %q contains unsafe char %q", out, c)
+			}
+		}
+
+		if FilterStr(out, n) != out {
+			t.Fatalf("filter of %q is not stable", out)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"net"
+	"strings"
+
+	"github.com/dropbox/godropbox/errors"
+	"github.com/pritunl/pritunl-web/errortypes"
+)
+
+func ParseCidrs(val string) (cidrs []*net.IPNet, err error) {
+	for _, item := range strings.Split(val, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				err = &errortypes.ParseError{
+					errors.Newf("utils: Invalid network address '%s'", item),
+				}
+				return
+			}
+
+			if ip.To4() != nil {
+				item += "/32"
+			} else {
+				item += "/128"
+			}
+		}
+
+		_, cidr, e := net.ParseCIDR(item)
+		if e != nil {
+			err = &errortypes.ParseError{
+				errors.Wrapf(e, "utils: Failed to parse network '%s'", item),
+			}
+			return
+		}
+
+		cidrs = append(cidrs, cidr)
+	}
+
+	return
+}
+
+func ContainsIp(cidrs []*net.IPNet, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	for _, cidr := range cidrs {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}